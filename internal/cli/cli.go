@@ -1,21 +1,25 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
 	"os"
+	"os/signal"
 	"strings"
 
 	"github.com/Xuanwo/go-locale"
 	"github.com/alecthomas/kong"
 	"github.com/fatih/color"
+	"github.com/pelletier/go-toml/v2"
 	"github.com/telecter/cmd-launcher/internal/cli/cmd"
 	"github.com/telecter/cmd-launcher/internal/cli/output"
 	"github.com/telecter/cmd-launcher/internal/meta"
 	"github.com/telecter/cmd-launcher/internal/network"
 	env "github.com/telecter/cmd-launcher/pkg"
 	"github.com/telecter/cmd-launcher/pkg/auth"
+	"github.com/telecter/cmd-launcher/pkg/launcher"
 	"go.abhg.dev/komplete"
 )
 
@@ -39,12 +43,17 @@ type CLI struct {
 	Instance    cmd.InstanceCmd  `cmd:"" help:"${instance}" aliases:"inst"`
 	Auth        cmd.AuthCmd      `cmd:"" help:"${auth}"`
 	Search      cmd.SearchCmd    `cmd:"" help:"${search}"`
+	Cache       cmd.CacheCmd     `cmd:"" help:"${cache}"`
+	Java        cmd.JavaCmd      `cmd:"" help:"${java}"`
+	Mod         cmd.ModCmd       `cmd:"" help:"${mod}"`
 	Completions komplete.Command `cmd:"" help:"${completions}"`
 	About       aboutCmd         `cmd:"" help:"${about}"`
 
-	Verbosity string `help:"${arg_verbosity}" enum:"info,extra,debug" default:"info"`
-	Dir       string `help:"${arg_dir}" type:"path" placeholder:"PATH"`
-	NoColor   bool   `help:"${arg_nocolor}"`
+	Verbosity string   `help:"${arg_verbosity}" enum:"info,extra,debug" default:"info"`
+	Dir       string   `help:"${arg_dir}" type:"path" placeholder:"PATH"`
+	NoColor   bool     `help:"${arg_nocolor}"`
+	Proxy     string   `help:"${arg_proxy}" placeholder:"URL"`
+	CACert    []string `help:"${arg_cacert}" name:"ca-cert" type:"existingfile" placeholder:"PATH"`
 }
 
 func (c *CLI) AfterApply(ctx *kong.Context) error {
@@ -63,6 +72,18 @@ func (c *CLI) AfterApply(ctx *kong.Context) error {
 			return err
 		}
 	}
+	launcher.LauncherName, launcher.LauncherVersion = name, version
+	err := launcher.ConfigureHTTP(launcher.HTTPConfig{
+		Proxy:     c.Proxy,
+		RootCAs:   c.CACert,
+		UserAgent: name + "/" + version,
+	})
+	if err != nil {
+		return fmt.Errorf("configure network: %w", err)
+	}
+	if err := loadMirrors(); err != nil {
+		return fmt.Errorf("load mirrors: %w", err)
+	}
 	if err := auth.ReadFromCache(); err != nil {
 		return fmt.Errorf("read auth store: %w", err)
 	}
@@ -72,6 +93,25 @@ func (c *CLI) AfterApply(ctx *kong.Context) error {
 	return nil
 }
 
+// loadMirrors reads the mirror configuration file, if present, and applies it.
+func loadMirrors() error {
+	data, err := os.ReadFile(env.MirrorsPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var config struct {
+		Mirrors []launcher.Mirror `toml:"mirror"`
+	}
+	if err := toml.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	launcher.SetMirrors(config.Mirrors...)
+	return nil
+}
+
 func vars() kong.Vars {
 	vars := make(kong.Vars)
 	for k, v := range output.Translations() {
@@ -91,6 +131,7 @@ func groups() kong.Groups {
 	return kong.Groups{
 		"overrides": output.Translate("start.arg.overrides"),
 		"opts":      output.Translate("start.arg.opts"),
+		"export":    output.Translate("start.arg.exportgroup"),
 	}
 }
 
@@ -105,13 +146,35 @@ func tips(err error) {
 		output.Tip(output.Translate("tip.cache"))
 	}
 	// Mojang-provided JVM isn't working
-	if errors.Is(err, meta.ErrJavaBadSystem) || errors.Is(err, meta.ErrJavaNoVersion) {
+	if errors.Is(err, meta.ErrJavaBadSystem) || errors.Is(err, meta.ErrJavaNoVersion) || errors.Is(err, launcher.ErrNoCompatibleJava) {
 		output.Tip(output.Translate("tip.nojvm"))
 	}
+	// Something needed was not downloaded before starting in offline mode
+	if errors.Is(err, network.ErrOffline) {
+		output.Tip(output.Translate("tip.offline"))
+	}
 	// Not logged in
 	if errors.Is(err, auth.ErrNoAccount) {
 		output.Tip(output.Translate("tip.noaccount"))
 	}
+	// Mods can't be managed for vanilla instances
+	if errors.Is(err, launcher.ErrModsUnsupported) {
+		output.Tip(output.Translate("tip.nomodloader"))
+	}
+	// CurseForge modpacks can't be downloaded without an API key
+	if errors.Is(err, meta.ErrCurseForgeUnauthorized) {
+		output.Tip(output.Translate("tip.curseforgekey"))
+	}
+	// A hook command failed
+	var hookErr *launcher.HookError
+	if errors.As(err, &hookErr) {
+		output.Tip(output.Translate("tip.hook"))
+	}
+	// The game crashed
+	var crash *launcher.CrashError
+	if errors.As(err, &crash) {
+		output.Tip(output.Translate("tip.crash"))
+	}
 }
 
 // Start creates the CLI parser and runs it. It returns an exit handler and code.
@@ -121,6 +184,11 @@ func Run() (func(int), int) {
 		output.SetLang(lang)
 	}
 
+	// The first interrupt cancels the running operation, a second one exits immediately.
+	cancelCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	context.AfterFunc(cancelCtx, stop)
+
 	parser := kong.Must(&CLI{},
 		kong.UsageOnError(),
 		kong.Name(name),
@@ -132,6 +200,7 @@ func Run() (func(int), int) {
 		kong.ValueFormatter(valueFormatter),
 		groups(),
 		vars(),
+		kong.BindTo(cancelCtx, (*context.Context)(nil)),
 	)
 	komplete.Run(parser)
 
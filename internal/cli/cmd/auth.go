@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 
@@ -26,19 +27,19 @@ type LoginCmd struct {
 	NoBrowser bool `help:"${login_arg_nobrowser}"`
 }
 
-func (c *LoginCmd) Run(ctx *kong.Context) error {
+func (c *LoginCmd) Run(ctx context.Context) error {
 	var session auth.Session
 
-	session, err := auth.Authenticate()
+	session, err := auth.AuthenticateContext(ctx)
 	if err != nil {
 		if c.NoBrowser {
 			output.Info(output.Translate("login.code.fetching"))
-			resp, err := auth.FetchDeviceCode()
+			resp, err := auth.FetchDeviceCodeContext(ctx)
 			if err != nil {
 				return fmt.Errorf("fetch device code: %w", err)
 			}
 			output.Info(output.Translate("login.code"), color.BlueString(resp.UserCode), color.BlueString(resp.VerificationURI))
-			session, err = auth.AuthenticateWithCode(resp)
+			session, err = auth.AuthenticateWithCodeContext(ctx, resp)
 			if err != nil {
 				return fmt.Errorf("add account: %w", err)
 			}
@@ -49,7 +50,7 @@ func (c *LoginCmd) Run(ctx *kong.Context) error {
 
 			browser.OpenURL(url.String())
 			var err error
-			session, err = auth.AuthenticateWithRedirect(output.Translate("login.redirect"), output.Translate("login.redirectfail"))
+			session, err = auth.AuthenticateWithRedirectContext(ctx, output.Translate("login.redirect"), output.Translate("login.redirectfail"))
 			if err != nil {
 				return fmt.Errorf("add account: %w", err)
 			}
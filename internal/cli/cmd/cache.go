@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/telecter/cmd-launcher/internal/cli/output"
+	"github.com/telecter/cmd-launcher/pkg/launcher"
+)
+
+// UsageCmd shows the disk usage of shared launcher files.
+type UsageCmd struct{}
+
+func (c *UsageCmd) Run() error {
+	usages, err := launcher.FetchStorageUsage()
+	if err != nil {
+		return fmt.Errorf("fetch storage usage: %w", err)
+	}
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.Style().Format.Footer = text.FormatDefault
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{
+		output.Translate("cache.table.category"),
+		output.Translate("cache.table.files"),
+		output.Translate("cache.table.size"),
+	})
+	var files int
+	var size int64
+	for _, usage := range usages {
+		t.AppendRow(table.Row{usage.Category, usage.Files, formatBytes(usage.Size)})
+		files += usage.Files
+		size += usage.Size
+	}
+	t.AppendFooter(table.Row{output.Translate("cache.table.total"), files, formatBytes(size)})
+	t.Render()
+	return nil
+}
+
+// VerifyCmd checks shared launcher files against their checksums.
+type VerifyCmd struct {
+	Fix bool `help:"${cache_arg_fix}"`
+}
+
+func (c *VerifyCmd) Run(ctx context.Context, verbosity int) error {
+	output.Info(output.Translate("cache.verify.running"))
+	invalid, err := launcher.VerifyStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("verify storage: %w", err)
+	}
+	if len(invalid) == 0 {
+		output.Success(output.Translate("cache.verify.complete"))
+		return nil
+	}
+	for _, file := range invalid {
+		output.Warning(output.Translate("cache.verify.invalid"), file.Path)
+	}
+	if !c.Fix {
+		output.Tip(output.Translate("tip.verify"))
+		return nil
+	}
+	if err := launcher.RemoveStorage(invalid); err != nil {
+		return fmt.Errorf("remove invalid files: %w", err)
+	}
+	output.Success(output.Translate("cache.verify.fixed"), len(invalid))
+	return nil
+}
+
+// PruneCmd removes shared launcher files which no instance uses.
+type PruneCmd struct {
+	DryRun bool `help:"${cache_arg_dryrun}"`
+	Yes    bool `name:"yes" short:"y" help:"${delete_arg_yes}"`
+}
+
+func (c *PruneCmd) Run(ctx context.Context, verbosity int) error {
+	unused, err := launcher.FetchUnusedStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch unused files: %w", err)
+	}
+	if len(unused) == 0 {
+		output.Success(output.Translate("cache.prune.none"))
+		return nil
+	}
+
+	var size int64
+	for _, file := range unused {
+		size += file.Size
+		if verbosity > 0 || c.DryRun {
+			output.Info("%s", file.Path)
+		}
+	}
+	output.Info(output.Translate("cache.prune.found"), len(unused), formatBytes(size))
+	if c.DryRun {
+		return nil
+	}
+
+	remove := c.Yes
+	if !remove {
+		var input string
+		fmt.Print(output.Translate("cache.prune.confirm"))
+		fmt.Scanln(&input)
+		remove = input == "y" || input == "Y"
+	}
+	if !remove {
+		output.Info(output.Translate("delete.abort"))
+		return nil
+	}
+	if err := launcher.RemoveStorage(unused); err != nil {
+		return fmt.Errorf("remove unused files: %w", err)
+	}
+	output.Success(output.Translate("cache.prune.complete"), formatBytes(size))
+	return nil
+}
+
+// CacheCmd enables management of files shared between instances, such as libraries, assets and Java runtimes.
+type CacheCmd struct {
+	Usage  UsageCmd  `cmd:"" help:"${cache_usage}"`
+	Verify VerifyCmd `cmd:"" help:"${cache_verify}"`
+	Prune  PruneCmd  `cmd:"" help:"${cache_prune}" aliases:"gc"`
+}
+
+// formatBytes formats a size in bytes with a binary unit, such as "1.5 MiB".
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"cmp"
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/fatih/color"
 	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/telecter/cmd-launcher/internal/cli/output"
 	"github.com/telecter/cmd-launcher/internal/meta"
 	"github.com/telecter/cmd-launcher/pkg/launcher"
@@ -20,7 +27,7 @@ type CreateCmd struct {
 	LoaderVersion string `help:"${create_arg_loaderversion}" default:"latest"`
 }
 
-func (c *CreateCmd) Run(ctx *kong.Context) error {
+func (c *CreateCmd) Run(ctx context.Context) error {
 	var loader meta.Loader
 	switch c.Loader {
 	case "fabric":
@@ -34,7 +41,7 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 	case "forge":
 		loader = meta.LoaderForge
 	}
-	inst, err := launcher.CreateInstance(launcher.InstanceOptions{
+	inst, err := launcher.CreateInstanceContext(ctx, launcher.InstanceOptions{
 		GameVersion:   c.Version,
 		Name:          c.ID,
 		Loader:        loader,
@@ -54,6 +61,102 @@ func (c *CreateCmd) Run(ctx *kong.Context) error {
 	return nil
 }
 
+// ImportCmd creates an instance from a modpack or a MultiMC/Prism Launcher instance.
+type ImportCmd struct {
+	File          string `arg:"" help:"${import_arg_file}" type:"path"`
+	Name          string `help:"${import_arg_name}" short:"n"`
+	Move          bool   `help:"${import_arg_move}"`
+	CurseForgeKey string `help:"${import_arg_curseforgekey}" name:"curseforge-key" env:"CURSEFORGE_API_KEY" placeholder:"KEY"`
+	ModsDir       string `help:"${import_arg_modsdir}" type:"existingdir" placeholder:"PATH"`
+}
+
+func (c *ImportCmd) Run(ctx context.Context, verbosity int) error {
+	options := launcher.InstanceOptions{
+		Name:   c.Name,
+		Config: defaultInstanceConfig,
+	}
+	var inst launcher.Instance
+	var skipped []error
+	var err error
+	if info, statErr := os.Stat(c.File); statErr == nil && info.IsDir() {
+		inst, skipped, err = launcher.ImportMultiMC(ctx, c.File, launcher.MultiMCImportOptions{
+			InstanceOptions: options,
+			Move:            c.Move,
+		})
+		if err != nil {
+			return fmt.Errorf("import instance: %w", err)
+		}
+	} else if strings.EqualFold(filepath.Ext(c.File), ".zip") {
+		var resolver launcher.CurseForgeResolver = launcher.CurseForgeAPIResolver{APIKey: c.CurseForgeKey}
+		if c.ModsDir != "" {
+			resolver = launcher.CurseForgeDirResolver{Dir: c.ModsDir}
+		}
+		inst, skipped, err = launcher.ImportCurseForge(ctx, c.File, options, resolver, watcher(verbosity))
+		if err != nil {
+			return fmt.Errorf("import modpack: %w", err)
+		}
+	} else {
+		inst, err = launcher.ImportMrpack(ctx, c.File, options, watcher(verbosity))
+		if err != nil {
+			return fmt.Errorf("import modpack: %w", err)
+		}
+	}
+	for _, err := range skipped {
+		output.Warning(output.Translate("import.unsupported"), err)
+	}
+
+	l := inst.LoaderVersion
+	if l != "" {
+		l = " " + l
+	}
+	output.Success(output.Translate("import.complete"), color.New(color.Bold).Sprint(inst.Name), inst.GameVersion, inst.Loader, l)
+	return nil
+}
+
+// ExportCmd exports an instance as a modpack.
+type ExportCmd struct {
+	ID               string   `arg:"" help:"${export_arg_id}"`
+	File             string   `arg:"" help:"${export_arg_file}" type:"path"`
+	Name             string   `help:"${export_arg_name}"`
+	Version          string   `help:"${export_arg_version}" default:"1.0.0"`
+	Summary          string   `help:"${export_arg_summary}"`
+	Include          []string `help:"${export_arg_include}" placeholder:"GLOB"`
+	Exclude          []string `help:"${export_arg_exclude}" placeholder:"GLOB"`
+	NoDefaultExclude bool     `help:"${export_arg_nodefaultexclude}"`
+}
+
+func (c *ExportCmd) Run() error {
+	inst, err := launcher.FetchInstance(c.ID)
+	if err != nil {
+		return err
+	}
+	exclude := c.Exclude
+	if !c.NoDefaultExclude {
+		exclude = append(exclude, launcher.DefaultMrpackExclude...)
+	}
+
+	f, err := os.Create(c.File)
+	if err != nil {
+		return fmt.Errorf("create modpack file: %w", err)
+	}
+	index, overrides, err := launcher.ExportMrpack(f, inst, launcher.MrpackExportOptions{
+		Name:    c.Name,
+		Version: c.Version,
+		Summary: c.Summary,
+		Include: c.Include,
+		Exclude: exclude,
+	})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(c.File)
+		return fmt.Errorf("export modpack: %w", err)
+	}
+	output.Success(output.Translate("export.complete"), color.New(color.Bold).Sprint(inst.Name), c.File, len(index.Files), len(overrides))
+	return nil
+}
+
 // DeleteCmd removes the specified instance.
 type DeleteCmd struct {
 	ID  string `arg:"" name:"id" help:"${delete_arg_id}"`
@@ -104,16 +207,51 @@ func (c *RenameCmd) Run(ctx *kong.Context) error {
 }
 
 // ListCmd lists all installed instances.
-type ListCmd struct{}
+type ListCmd struct {
+	Sort    string `help:"${list_arg_sort}" enum:"name,last-played,playtime" default:"name" short:"s"`
+	Reverse bool   `help:"${list_arg_reverse}" short:"r"`
+}
 
 func (c *ListCmd) Run(ctx *kong.Context) error {
-	var rows []table.Row
 	instances, err := launcher.FetchAllInstances()
 	if err != nil {
 		return fmt.Errorf("fetch all instances: %w", err)
 	}
+	type playtime struct {
+		lastPlayed time.Time
+		total      time.Duration
+	}
+	playtimes := make(map[string]playtime)
+	for _, inst := range instances {
+		lastPlayed, total, err := inst.Playtime()
+		if err != nil {
+			return fmt.Errorf("read history of instance %q: %w", inst.Name, err)
+		}
+		playtimes[inst.Name] = playtime{lastPlayed, total}
+	}
+
+	// Most recently or longest played instances come first
+	switch c.Sort {
+	case "last-played":
+		slices.SortStableFunc(instances, func(a, b launcher.Instance) int {
+			return playtimes[b.Name].lastPlayed.Compare(playtimes[a.Name].lastPlayed)
+		})
+	case "playtime":
+		slices.SortStableFunc(instances, func(a, b launcher.Instance) int {
+			return cmp.Compare(playtimes[b.Name].total, playtimes[a.Name].total)
+		})
+	}
+	if c.Reverse {
+		slices.Reverse(instances)
+	}
+
+	var rows []table.Row
 	for i, inst := range instances {
-		rows = append(rows, table.Row{i, inst.Name, inst.GameVersion, inst.Loader})
+		lastPlayed := output.Translate("list.never")
+		if p := playtimes[inst.Name]; !p.lastPlayed.IsZero() {
+			lastPlayed = p.lastPlayed.Format(time.DateTime)
+		}
+		rows = append(rows, table.Row{i, inst.Name, inst.GameVersion, inst.Loader, lastPlayed, formatDuration(playtimes[inst.Name].total)})
 	}
 
 	t := table.NewWriter()
@@ -124,18 +262,73 @@ func (c *ListCmd) Run(ctx *kong.Context) error {
 		output.Translate("search.table.name"),
 		output.Translate("search.table.version"),
 		output.Translate("search.table.type"),
+		output.Translate("list.table.lastplayed"),
+		output.Translate("list.table.playtime"),
 	})
 	t.AppendRows(rows)
 	t.Render()
 	return nil
 }
 
+// HistoryCmd shows the past sessions of an instance.
+type HistoryCmd struct {
+	ID string `arg:"" help:"${history_arg_id}"`
+}
+
+func (c *HistoryCmd) Run(ctx *kong.Context) error {
+	inst, err := launcher.FetchInstance(c.ID)
+	if err != nil {
+		return err
+	}
+	history, err := inst.History()
+	if err != nil {
+		return fmt.Errorf("read history: %w", err)
+	}
+	if len(history) == 0 {
+		output.Info(output.Translate("history.none"), inst.Name)
+		return nil
+	}
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.Style().Format.Footer = text.FormatDefault
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{
+		output.Translate("history.table.start"),
+		output.Translate("history.table.duration"),
+		output.Translate("history.table.exitcode"),
+		output.Translate("history.table.account"),
+		output.Translate("search.table.version"),
+	})
+	var total time.Duration
+	for _, entry := range history {
+		version := entry.GameVersion
+		if entry.Loader != meta.LoaderVanilla && entry.Loader != "" {
+			version += fmt.Sprintf(" (%s %s)", entry.Loader, entry.LoaderVersion)
+		}
+		t.AppendRow(table.Row{entry.Start.Format(time.DateTime), formatDuration(entry.Duration()), entry.ExitCode, entry.Username, version})
+		total += entry.Duration()
+	}
+	t.AppendFooter(table.Row{output.Translate("cache.table.total"), formatDuration(total)})
+	t.Render()
+	return nil
+}
+
+// formatDuration formats a duration in hours and minutes, such as "1h 05m".
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
 // InstanceCmd enables management of Minecraft instances.
 type InstanceCmd struct {
-	Create CreateCmd `cmd:"" help:"${create}"`
-	Delete DeleteCmd `cmd:"" help:"${delete}"`
-	Rename RenameCmd `cmd:"" help:"${rename}"`
-	List   ListCmd   `cmd:"" help:"${list}"`
+	Create  CreateCmd  `cmd:"" help:"${create}"`
+	Import  ImportCmd  `cmd:"" help:"${import}"`
+	Export  ExportCmd  `cmd:"" help:"${export}"`
+	Delete  DeleteCmd  `cmd:"" help:"${delete}"`
+	Rename  RenameCmd  `cmd:"" help:"${rename}"`
+	List    ListCmd    `cmd:"" help:"${list}"`
+	History HistoryCmd `cmd:"" help:"${history}"`
 }
 
 var defaultInstanceConfig = launcher.InstanceConfig{
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/telecter/cmd-launcher/internal/cli/output"
+	"github.com/telecter/cmd-launcher/pkg/launcher"
+)
+
+// JavaListCmd lists installed and available Mojang-provided Java runtimes.
+type JavaListCmd struct{}
+
+func (c *JavaListCmd) Run(ctx context.Context) error {
+	runtimes, err := launcher.FetchJavaRuntimes(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch Java runtimes: %w", err)
+	}
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{
+		output.Translate("java.table.component"),
+		output.Translate("java.table.version"),
+		output.Translate("java.table.released"),
+		output.Translate("java.table.installed"),
+	})
+	for _, runtime := range runtimes {
+		version, released, installed := "-", "-", output.Translate("java.no")
+		if runtime.Available {
+			version = runtime.Version
+			released = runtime.Released.Format(time.DateOnly)
+		}
+		if runtime.Installed {
+			installed = formatBytes(runtime.Size)
+		}
+		t.AppendRow(table.Row{runtime.Component, version, released, installed})
+	}
+	t.Render()
+	return nil
+}
+
+// JavaInstallCmd downloads a Mojang-provided Java runtime ahead of time.
+type JavaInstallCmd struct {
+	Component string `arg:"" help:"${java_arg_component}"`
+}
+
+func (c *JavaInstallCmd) Run(ctx context.Context, verbosity int) error {
+	if err := launcher.InstallJavaRuntime(ctx, c.Component, watcher(verbosity)); err != nil {
+		return fmt.Errorf("install Java runtime: %w", err)
+	}
+	output.Success(output.Translate("java.install.complete"), c.Component)
+	return nil
+}
+
+// JavaVerifyCmd checks installed Java runtimes against their manifests and repairs them.
+type JavaVerifyCmd struct {
+	Components []string `arg:"" optional:"" help:"${java_arg_components}"`
+}
+
+func (c *JavaVerifyCmd) Run(ctx context.Context, verbosity int) error {
+	components := c.Components
+	if len(components) == 0 {
+		runtimes, err := launcher.FetchJavaRuntimes(ctx)
+		if err != nil {
+			return fmt.Errorf("fetch Java runtimes: %w", err)
+		}
+		for _, runtime := range runtimes {
+			if runtime.Installed && runtime.Available {
+				components = append(components, runtime.Component)
+			}
+		}
+	}
+	if len(components) == 0 {
+		output.Info(output.Translate("java.verify.none"))
+		return nil
+	}
+
+	for _, component := range components {
+		output.Info(output.Translate("java.verify.running"), component)
+		invalid, err := launcher.VerifyJavaRuntime(ctx, component)
+		if err != nil {
+			return fmt.Errorf("verify Java runtime %q: %w", component, err)
+		}
+		if len(invalid) == 0 {
+			output.Success(output.Translate("java.verify.complete"), component)
+			continue
+		}
+		for _, file := range invalid {
+			output.Warning(output.Translate("java.verify.invalid"), file.Path)
+		}
+		if err := launcher.InstallJavaRuntime(ctx, component, watcher(verbosity)); err != nil {
+			return fmt.Errorf("repair Java runtime %q: %w", component, err)
+		}
+		output.Success(output.Translate("java.verify.fixed"), len(invalid), component)
+	}
+	return nil
+}
+
+// JavaRemoveCmd removes an installed Java runtime.
+type JavaRemoveCmd struct {
+	Component string `arg:"" help:"${java_arg_component}"`
+	Yes       bool   `name:"yes" short:"y" help:"${delete_arg_yes}"`
+}
+
+func (c *JavaRemoveCmd) Run() error {
+	remove := c.Yes
+	if !remove {
+		var input string
+		fmt.Printf(output.Translate("java.remove.confirm"), color.New(color.Bold).Sprint(c.Component))
+		fmt.Scanln(&input)
+		remove = input == "y" || input == "Y"
+	}
+	if !remove {
+		output.Info(output.Translate("delete.abort"))
+		return nil
+	}
+	if err := launcher.RemoveJavaRuntime(c.Component); err != nil {
+		return fmt.Errorf("remove Java runtime: %w", err)
+	}
+	output.Success(output.Translate("java.remove.complete"), c.Component)
+	return nil
+}
+
+// JavaCmd enables management of Mojang-provided Java runtimes.
+type JavaCmd struct {
+	List    JavaListCmd    `cmd:"" help:"${java_list}" aliases:"ls"`
+	Install JavaInstallCmd `cmd:"" help:"${java_install}"`
+	Verify  JavaVerifyCmd  `cmd:"" help:"${java_verify}"`
+	Remove  JavaRemoveCmd  `cmd:"" help:"${java_remove}" aliases:"rm"`
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/telecter/cmd-launcher/internal/cli/output"
+	"github.com/telecter/cmd-launcher/pkg/launcher"
+)
+
+// modWatcher returns an event watcher which shows download progress and resolved mods.
+func modWatcher(verbosity int) launcher.EventWatcher {
+	downloads := watcher(verbosity)
+	return func(event any) {
+		switch e := event.(type) {
+		case launcher.ModResolvedEvent:
+			if verbosity > 0 || e.Mod.Dependency {
+				output.Info(output.Translate("mod.resolved"), e.Mod.Title, e.Mod.Version)
+			}
+		default:
+			downloads(event)
+		}
+	}
+}
+
+// ModSearchCmd searches Modrinth for mods compatible with an instance.
+type ModSearchCmd struct {
+	ID    string `arg:"" help:"${mod_arg_id}"`
+	Query string `arg:"" help:"${search_arg_query}" optional:""`
+	Limit int    `help:"${mod_arg_limit}" short:"n" default:"10"`
+}
+
+func (c *ModSearchCmd) Run(ctx context.Context) error {
+	inst, err := launcher.FetchInstance(c.ID)
+	if err != nil {
+		return err
+	}
+	hits, err := launcher.SearchMods(ctx, inst, c.Query, c.Limit)
+	if err != nil {
+		return fmt.Errorf("search mods: %w", err)
+	}
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{
+		output.Translate("mod.table.slug"),
+		output.Translate("search.table.name"),
+		output.Translate("mod.table.author"),
+		output.Translate("mod.table.downloads"),
+	})
+	for _, hit := range hits {
+		t.AppendRow(table.Row{hit.Slug, hit.Title, hit.Author, hit.Downloads})
+	}
+	t.Render()
+	output.Info(output.Translate("search.complete"), len(hits))
+	return nil
+}
+
+// ModAddCmd installs mods from Modrinth in an instance.
+type ModAddCmd struct {
+	ID       string   `arg:"" help:"${mod_arg_id}"`
+	Projects []string `arg:"" help:"${mod_arg_projects}"`
+}
+
+func (c *ModAddCmd) Run(ctx context.Context, verbosity int) error {
+	inst, err := launcher.FetchInstance(c.ID)
+	if err != nil {
+		return err
+	}
+	added, err := launcher.AddMods(ctx, inst, c.Projects, modWatcher(verbosity))
+	if err != nil {
+		return fmt.Errorf("add mods: %w", err)
+	}
+	for _, mod := range added {
+		output.Success(output.Translate("mod.add.complete"), mod.Title, mod.Version)
+	}
+	return nil
+}
+
+// ModRemoveCmd removes mods from an instance.
+type ModRemoveCmd struct {
+	ID       string   `arg:"" help:"${mod_arg_id}"`
+	Projects []string `arg:"" help:"${mod_arg_installed}"`
+}
+
+func (c *ModRemoveCmd) Run() error {
+	inst, err := launcher.FetchInstance(c.ID)
+	if err != nil {
+		return err
+	}
+	removed, err := launcher.RemoveMods(inst, c.Projects)
+	if err != nil {
+		return fmt.Errorf("remove mods: %w", err)
+	}
+	for _, mod := range removed {
+		output.Success(output.Translate("mod.remove.complete"), mod.Title)
+	}
+	return nil
+}
+
+// ModListCmd lists the mods installed in an instance.
+type ModListCmd struct {
+	ID string `arg:"" help:"${mod_arg_id}"`
+}
+
+func (c *ModListCmd) Run() error {
+	inst, err := launcher.FetchInstance(c.ID)
+	if err != nil {
+		return err
+	}
+	mods, err := inst.Mods()
+	if err != nil {
+		return err
+	}
+	if len(mods) == 0 {
+		output.Info(output.Translate("mod.list.none"), inst.Name)
+		return nil
+	}
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{
+		output.Translate("search.table.name"),
+		output.Translate("search.table.version"),
+		output.Translate("mod.table.file"),
+		output.Translate("mod.table.dependency"),
+	})
+	for _, mod := range mods {
+		dependency := ""
+		if mod.Dependency {
+			dependency = "✓"
+		}
+		t.AppendRow(table.Row{mod.Title, mod.Version, mod.File, dependency})
+	}
+	t.Render()
+	return nil
+}
+
+// ModUpdateCmd updates the mods of an instance.
+type ModUpdateCmd struct {
+	ID       string   `arg:"" help:"${mod_arg_id}"`
+	Projects []string `arg:"" help:"${mod_arg_update}" optional:""`
+}
+
+func (c *ModUpdateCmd) Run(ctx context.Context, verbosity int) error {
+	inst, err := launcher.FetchInstance(c.ID)
+	if err != nil {
+		return err
+	}
+	updates, err := launcher.UpdateMods(ctx, inst, c.Projects, modWatcher(verbosity))
+	if err != nil {
+		return fmt.Errorf("update mods: %w", err)
+	}
+	if len(updates) == 0 {
+		output.Success(output.Translate("mod.update.none"))
+		return nil
+	}
+	for _, update := range updates {
+		if update.Err != nil {
+			output.Warning(output.Translate("mod.update.skipped"), update.Old.Title, update.Err)
+			continue
+		}
+		output.Success(output.Translate("mod.update.complete"), update.New.Title, update.Old.Version, update.New.Version)
+	}
+	return nil
+}
+
+// ModCmd enables management of an instance's mods from Modrinth.
+type ModCmd struct {
+	Search ModSearchCmd `cmd:"" help:"${mod_search}"`
+	Add    ModAddCmd    `cmd:"" help:"${mod_add}"`
+	Remove ModRemoveCmd `cmd:"" help:"${mod_remove}" aliases:"rm"`
+	List   ModListCmd   `cmd:"" help:"${mod_list}" aliases:"ls"`
+	Update ModUpdateCmd `cmd:"" help:"${mod_update}"`
+}
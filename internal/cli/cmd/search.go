@@ -1,13 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"slices"
 	"strings"
 	"time"
 
-	"github.com/alecthomas/kong"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/telecter/cmd-launcher/internal/cli/output"
 	"github.com/telecter/cmd-launcher/internal/meta"
@@ -20,7 +20,7 @@ type SearchCmd struct {
 	Reverse bool   `short:"r" help:"${search_arg_reverse}"`
 }
 
-func (c *SearchCmd) Run(ctx *kong.Context) error {
+func (c *SearchCmd) Run(ctx context.Context) error {
 	var rows []table.Row
 	var header table.Row
 
@@ -31,7 +31,7 @@ func (c *SearchCmd) Run(ctx *kong.Context) error {
 			output.Translate("search.table.type"),
 			output.Translate("search.table.date"),
 		}
-		manifest, err := meta.FetchVersionManifest()
+		manifest, err := meta.FetchVersionManifest(ctx)
 		if err != nil {
 			return fmt.Errorf("retrieve version manifest: %w", err)
 		}
@@ -51,7 +51,7 @@ func (c *SearchCmd) Run(ctx *kong.Context) error {
 			api = meta.Quilt
 		}
 
-		versions, err := api.FetchVersions()
+		versions, err := api.FetchVersions(ctx)
 		if err != nil {
 			return fmt.Errorf("retrieve versions: %w", err)
 		}
@@ -67,7 +67,7 @@ func (c *SearchCmd) Run(ctx *kong.Context) error {
 			"Type",
 		}
 
-		versions, err := meta.FetchForgePromotions()
+		versions, err := meta.FetchForgePromotions(ctx)
 		if err != nil {
 			return fmt.Errorf("retrieve Forge versions: %w", err)
 		}
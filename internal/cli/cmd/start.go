@@ -1,25 +1,29 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"time"
 
-	"github.com/alecthomas/kong"
 	"github.com/fatih/color"
 	"github.com/schollz/progressbar/v3"
 	"github.com/telecter/cmd-launcher/internal/cli/output"
+	"github.com/telecter/cmd-launcher/internal/network"
 	"github.com/telecter/cmd-launcher/pkg/auth"
 	"github.com/telecter/cmd-launcher/pkg/launcher"
 )
 
 func watcher(verbosity int) launcher.EventWatcher {
-	var bar = progressbar.NewOptions(0,
+	var bar = progressbar.NewOptions64(0,
 		progressbar.OptionSetDescription(output.Translate("start.launch.downloading")),
 		progressbar.OptionSetWriter(os.Stdout),
 		progressbar.OptionThrottle(65*time.Millisecond),
-		progressbar.OptionShowCount(),
-		progressbar.OptionShowIts(),
+		progressbar.OptionShowBytes(true),
+		progressbar.OptionShowTotalBytes(true),
+		progressbar.OptionSetPredictTime(false),
 		progressbar.OptionOnCompletion(func() {
 			fmt.Print("\n")
 		}),
@@ -27,8 +31,17 @@ func watcher(verbosity int) launcher.EventWatcher {
 	return func(event any) {
 		switch e := event.(type) {
 		case launcher.DownloadingEvent:
-			bar.ChangeMax(e.Total)
-			bar.Add(1)
+			eta := "?"
+			if e.ETA > 0 {
+				eta = e.ETA.Round(time.Second).String()
+			}
+			bar.Describe(fmt.Sprintf(output.Translate("start.launch.downloading.progress"), e.Completed, e.Total, eta))
+			bar.ChangeMax64(e.BytesTotal)
+			bar.Set64(e.BytesCompleted)
+		case launcher.DownloadStartedEvent:
+			if verbosity > 1 {
+				output.Debug(output.Translate("start.launch.downloading.file"), e.URL)
+			}
 		case launcher.AssetsResolvedEvent:
 			if verbosity > 0 {
 				output.Info(output.Translate("start.launch.assets"), e.Total)
@@ -43,6 +56,14 @@ func watcher(verbosity int) launcher.EventWatcher {
 			}
 		case launcher.PostProcessingEvent:
 			output.Info(output.Translate("start.processing"))
+		case launcher.ModpackModsNotRecordedEvent:
+			output.Warning(output.Translate("import.mods.unrecorded"), e.Err)
+		case launcher.SystemJavaSelectedEvent:
+			if verbosity > 0 {
+				output.Info(output.Translate("start.java.system"), e.Installation.MajorVersion, e.Installation.Vendor, e.Installation.Path)
+			}
+		case launcher.JavaVersionMismatchEvent:
+			output.Warning(output.Translate("start.java.mismatch"), e.Path, e.Found, e.Required)
 		}
 	}
 }
@@ -52,6 +73,7 @@ type StartCmd struct {
 	ID string `arg:"" help:"${start_arg_id}"`
 
 	Prepare bool `help:"${start_arg_prepare}"`
+	Offline bool `help:"${start_arg_offline}"`
 
 	Options struct {
 		Username    string `help:"${start_arg_username}" short:"u"`
@@ -69,9 +91,15 @@ type StartCmd struct {
 		MinMemory int    `help:"${start_arg_minmemory}" placeholder:"MB" and:"memory"`
 		MaxMemory int    `help:"${start_arg_maxmemory}" placeholder:"MB" and:"memory"`
 	} `embed:"" group:"overrides"`
+	Export struct {
+		Export    string `help:"${start_arg_export}" type:"path" placeholder:"FILE"`
+		Format    string `help:"${start_arg_format}" enum:"script,json" default:"script"`
+		TokenEnv  string `help:"${start_arg_tokenenv}" placeholder:"VAR" xor:"token"`
+		KeepToken bool   `help:"${start_arg_keeptoken}" xor:"token"`
+	} `embed:"" group:"export"`
 }
 
-func (c *StartCmd) Run(ctx *kong.Context, verbosity int) error {
+func (c *StartCmd) Run(ctx context.Context, verbosity int) error {
 	inst, err := launcher.FetchInstance(c.ID)
 	if err != nil {
 		return err
@@ -109,13 +137,25 @@ func (c *StartCmd) Run(ctx *kong.Context, verbosity int) error {
 		Username: c.Options.Username,
 	}
 	if c.Options.Username == "" {
-		session, err = auth.Authenticate()
-		if err != nil {
+		authCtx := ctx
+		if c.Offline {
+			authCtx = network.WithOffline(ctx)
+		}
+		session, err = auth.AuthenticateContext(authCtx)
+		if errors.Is(err, network.ErrOffline) && auth.Store.Minecraft.Username != "" {
+			// Tokens can't be refreshed without network access, but singleplayer still works with the stored profile
+			output.Warning(output.Translate("start.offline.auth"))
+			session = auth.Session{
+				Username: auth.Store.Minecraft.Username,
+				UUID:     auth.Store.Minecraft.UUID,
+			}
+		} else if err != nil {
 			return fmt.Errorf("authenticate session: %w", err)
 		}
 	}
 
-	launchEnv, err := launcher.Prepare(
+	launchEnv, err := launcher.PrepareContext(
+		ctx,
 		&inst,
 		launcher.LaunchOptions{
 			Session: session,
@@ -126,6 +166,7 @@ func (c *StartCmd) Run(ctx *kong.Context, verbosity int) error {
 			Demo:               c.Options.Demo,
 			DisableMultiplayer: c.Options.DisableMP,
 			DisableChat:        c.Options.DisableChat,
+			Offline:            c.Offline,
 		},
 		watcher(verbosity))
 
@@ -133,6 +174,9 @@ func (c *StartCmd) Run(ctx *kong.Context, verbosity int) error {
 		return err
 	}
 
+	if c.Export.Export != "" {
+		return c.export(launchEnv, session)
+	}
 	if c.Prepare {
 		output.Success(output.Translate("start.prepared"))
 		return nil
@@ -149,7 +193,7 @@ func (c *StartCmd) Run(ctx *kong.Context, verbosity int) error {
 			} else {
 				gameArgs = append(gameArgs, arg)
 			}
-			if arg == "--accessToken" || arg == "--uuid" {
+			if arg == "--accessToken" || arg == "--uuid" || arg == "--session" {
 				hideNext = true
 			} else {
 				hideNext = false
@@ -160,5 +204,68 @@ func (c *StartCmd) Run(ctx *kong.Context, verbosity int) error {
 	}
 	output.Success(output.Translate("start.launch"), color.New(color.Bold).Sprint(session.Username))
 
-	return launcher.Launch(launchEnv, launcher.ConsoleRunner)
+	err = launcher.Launch(launchEnv, launcher.LogRunner(printLog))
+	var crash *launcher.CrashError
+	if errors.As(err, &crash) {
+		if crash.Report != "" {
+			output.Info(output.Translate("start.crash.report"), crash.Report)
+		}
+		if crash.Mod != "" {
+			output.Info(output.Translate("start.crash.mod"), crash.Mod)
+		}
+	}
+	return err
+}
+
+// export writes the launch environment to the export file instead of starting the game.
+func (c *StartCmd) export(launchEnv launcher.LaunchEnvironment, session auth.Session) error {
+	options := launcher.ExportOptions{
+		AccessToken: session.AccessToken,
+		TokenEnv:    c.Export.TokenEnv,
+		KeepToken:   c.Export.KeepToken,
+	}
+	var buf bytes.Buffer
+	mode := os.FileMode(0644)
+	switch c.Export.Format {
+	case "script":
+		if err := launcher.ExportLaunchScript(&buf, launchEnv, options); err != nil {
+			return fmt.Errorf("export launch script: %w", err)
+		}
+		mode = 0755
+	case "json":
+		if err := launcher.ExportLaunchJSON(&buf, launchEnv, options); err != nil {
+			return fmt.Errorf("export launch spec: %w", err)
+		}
+	}
+	if options.KeepToken && session.AccessToken != "" {
+		// The file contains credentials
+		mode &^= 0077
+	}
+	if err := os.WriteFile(c.Export.Export, buf.Bytes(), mode); err != nil {
+		return fmt.Errorf("write export: %w", err)
+	}
+	output.Success(output.Translate("start.exported"), c.Export.Export)
+	return nil
+}
+
+// printLog prints messages of the game to the console, coloured by their level.
+func printLog(event any) {
+	e, ok := event.(launcher.LogEvent)
+	if !ok {
+		return
+	}
+	c := color.New()
+	switch e.Level {
+	case "WARN":
+		c.Add(color.FgYellow)
+	case "ERROR", "FATAL":
+		c.Add(color.FgRed)
+	case "DEBUG", "TRACE":
+		c.Add(color.Faint)
+	}
+	if e.Stderr {
+		c.Fprintln(os.Stderr, e)
+	} else {
+		c.Println(e)
+	}
 }
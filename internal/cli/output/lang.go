@@ -39,59 +39,173 @@ var en = translations{
 	"delete.arg.id":   "Instance to delete",
 	"delete.arg.yes":  "Assume yes to all questions",
 
-	"rename":          "Rename an instance",
-	"rename.complete": "Renamed instance.",
-	"rename.arg.id":   "Instance to rename",
-	"rename.arg.new":  "New name for instance",
-
-	"search":               "Search versions",
-	"search.complete":      "Found %d entries",
-	"search.table.version": "Version",
-	"search.table.type":    "Type",
-	"search.table.date":    "Release Date",
-	"search.table.name":    "Name",
-	"search.arg.query":     "Search query",
-	"search.arg.kind":      "What to search for",
-	"search.arg.reverse":   "Reverse the listing",
-
-	"start":                    "Start the specified instance",
-	"start.arg.id":             "Instance to launch",
-	"start.arg.verbose":        "Increase verbosity",
-	"start.arg.username":       "Set username (offline mode)",
-	"start.arg.server":         "Join a server upon starting the game",
-	"start.arg.world":          "Join a world upon starting the game",
-	"start.arg.demo":           "Start the game in demo mode",
-	"start.arg.disablemp":      "Disable multiplayer",
-	"start.arg.disablechat":    "Disable chat",
-	"start.arg.width":          "Game window width",
-	"start.arg.height":         "Game window height",
-	"start.arg.jvm":            "Path to the JVM",
-	"start.arg.jvmargs":        "Extra JVM arguments",
-	"start.arg.minmemory":      "Minimum memory",
-	"start.arg.maxmemory":      "Maximum memory",
-	"start.arg.prepare":        "Install all necessary resources but do not start the game.",
-	"start.arg.opts":           "Game Options",
-	"start.arg.overrides":      "Configuration Overrides",
-	"start.prepared":           "Game prepared successfully.",
-	"start.processing":         "Post processors are being run. This may take some time.",
-	"start.launch.downloading": "Downloading files",
-	"start.launch.assets":      "Identified %d assets",
-	"start.launch.libraries":   "Identified %d libraries",
-	"start.launch.metadata":    "Version metadata retrieved",
-	"start.launch.jvmargs":     "JVM arguments: %s",
-	"start.launch.gameargs":    "Game arguments: %s",
-	"start.launch.info":        "Starting main class %q. Game directory is %q.",
-	"start.launch":             "Launching game as %s",
+	"rename":                      "Rename an instance",
+	"rename.complete":             "Renamed instance.",
+	"rename.arg.id":               "Instance to rename",
+	"rename.arg.new":              "New name for instance",
+	"list.arg.sort":               "Sort instances by name, last played time or total playtime",
+	"list.arg.reverse":            "Reverse the order of instances",
+	"list.table.lastplayed":       "Last Played",
+	"list.table.playtime":         "Playtime",
+	"list.never":                  "Never",
+	"history":                     "Show the past sessions of an instance",
+	"history.arg.id":              "Instance to show the history of",
+	"history.none":                "Instance '%s' has not been played yet.",
+	"history.table.start":         "Started",
+	"history.table.duration":      "Duration",
+	"history.table.exitcode":      "Exit Code",
+	"history.table.account":       "Account",
+	"import":                      "Create an instance from a Modrinth (.mrpack) or CurseForge (.zip) modpack, or a MultiMC/Prism Launcher instance",
+	"import.complete":             "Imported '%s' with Minecraft %s (%s%s)",
+	"import.arg.file":             "Modpack file or MultiMC/Prism Launcher instance directory to import",
+	"import.arg.name":             "Name of the instance. Defaults to the name of the modpack or instance.",
+	"import.arg.move":             "Move the game directory of a MultiMC/Prism Launcher instance instead of copying it",
+	"import.arg.curseforgekey":    "CurseForge API key used to download the mods of CurseForge modpacks",
+	"import.arg.modsdir":          "Directory to take the mods of CurseForge modpacks from instead, as <project ID>/<file ID>/<file>",
+	"import.unsupported":          "Not imported: %s",
+	"import.mods.unrecorded":      "Mods of the modpack could not be recorded, so they can't be updated: %s",
+	"export":                      "Export an instance as a Modrinth modpack (.mrpack)",
+	"export.complete":             "Exported '%s' to %s (%d downloaded files, %d overrides)",
+	"export.arg.id":               "Instance to export",
+	"export.arg.file":             "Modpack file to write",
+	"export.arg.name":             "Name of the modpack. Defaults to the name of the instance.",
+	"export.arg.version":          "Version of the modpack",
+	"export.arg.summary":          "Short description of the modpack",
+	"export.arg.include":          "Only include files matching a glob, relative to the instance directory. Can be repeated.",
+	"export.arg.exclude":          "Leave out files matching a glob, relative to the instance directory. Can be repeated.",
+	"export.arg.nodefaultexclude": "Also include logs, crash reports, worlds and screenshots",
+
+	"search":                "Search versions",
+	"search.complete":       "Found %d entries",
+	"search.table.version":  "Version",
+	"search.table.type":     "Type",
+	"search.table.date":     "Release Date",
+	"search.table.name":     "Name",
+	"search.arg.query":      "Search query",
+	"search.arg.kind":       "What to search for",
+	"search.arg.reverse":    "Reverse the listing",
+	"cache":                 "Manage files shared between instances",
+	"cache.usage":           "Show disk usage of shared files",
+	"cache.verify":          "Check shared files against their checksums",
+	"cache.prune":           "Remove shared files which no instance uses",
+	"cache.table.category":  "Category",
+	"cache.table.files":     "Files",
+	"cache.table.size":      "Size",
+	"cache.table.total":     "Total",
+	"cache.verify.running":  "Verifying files. This may take some time.",
+	"cache.verify.complete": "All files are valid.",
+	"cache.verify.invalid":  "Invalid file: %s",
+	"cache.verify.fixed":    "Removed %d invalid files. They will be downloaded again when needed.",
+	"cache.prune.none":      "No unused files found.",
+	"cache.prune.found":     "Found %d unused files (%s)",
+	"cache.prune.confirm":   "Remove them? [y/n] ",
+	"cache.prune.complete":  "Freed %s",
+	"cache.arg.fix":         "Remove invalid files so they are downloaded again",
+	"cache.arg.dryrun":      "Only list unused files, without removing them",
+	"java":                  "Manage Mojang-provided Java runtimes",
+	"java.list":             "List installed and available Java runtimes",
+	"java.install":          "Download a Java runtime",
+	"java.verify":           "Check installed Java runtimes against their manifests and repair them",
+	"java.remove":           "Remove an installed Java runtime",
+	"java.table.component":  "Component",
+	"java.table.version":    "Version",
+	"java.table.released":   "Released",
+	"java.table.installed":  "Installed",
+	"java.no":               "No",
+	"java.install.complete": "Installed Java runtime %s",
+	"java.verify.none":      "No Java runtimes are installed.",
+	"java.verify.running":   "Verifying Java runtime %s",
+	"java.verify.invalid":   "Invalid runtime file: %s",
+	"java.verify.complete":  "All files of %s are valid.",
+	"java.verify.fixed":     "Repaired %d files of %s",
+	"java.remove.confirm":   "Remove Java runtime '%s'? It will be downloaded again when an instance needs it. [y/n] ",
+	"java.remove.complete":  "Removed Java runtime %s",
+	"java.arg.component":    "Java runtime component, such as java-runtime-delta",
+	"java.arg.components":   "Java runtime components to verify. If none are specified, all installed runtimes are verified.",
+	"mod":                   "Manage an instance's mods from Modrinth",
+	"mod.search":            "Search Modrinth for mods compatible with an instance",
+	"mod.add":               "Install mods and their dependencies",
+	"mod.remove":            "Remove mods and dependencies no other mod requires",
+	"mod.list":              "List installed mods",
+	"mod.update":            "Update mods to their newest compatible version",
+	"mod.table.slug":        "Slug",
+	"mod.table.author":      "Author",
+	"mod.table.downloads":   "Downloads",
+	"mod.table.file":        "File",
+	"mod.table.dependency":  "Dependency",
+	"mod.resolved":          "Resolved %s %s",
+	"mod.add.complete":      "Added %s %s",
+	"mod.remove.complete":   "Removed %s",
+	"mod.list.none":         "No mods are installed in '%s'.",
+	"mod.update.none":       "All mods are up to date.",
+	"mod.update.complete":   "Updated %s from %s to %s",
+	"mod.update.skipped":    "Kept %s, it could not be updated: %s",
+	"mod.arg.id":            "Instance to manage mods of",
+	"mod.arg.limit":         "Maximum number of results",
+	"mod.arg.projects":      "Modrinth projects to install, by slug or ID",
+	"mod.arg.installed":     "Installed mods, by slug, ID or name",
+	"mod.arg.update":        "Installed mods to update, by slug, ID or name. If none are specified, all mods are updated.",
+
+	"start":                             "Start the specified instance",
+	"start.arg.id":                      "Instance to launch",
+	"start.arg.verbose":                 "Increase verbosity",
+	"start.arg.username":                "Set username (offline mode)",
+	"start.arg.server":                  "Join a server upon starting the game",
+	"start.arg.world":                   "Join a world upon starting the game",
+	"start.arg.demo":                    "Start the game in demo mode",
+	"start.arg.disablemp":               "Disable multiplayer",
+	"start.arg.disablechat":             "Disable chat",
+	"start.arg.width":                   "Game window width",
+	"start.arg.height":                  "Game window height",
+	"start.arg.jvm":                     "Path to the JVM",
+	"start.arg.jvmargs":                 "Extra JVM arguments",
+	"start.arg.minmemory":               "Minimum memory",
+	"start.arg.maxmemory":               "Maximum memory",
+	"start.arg.prepare":                 "Install all necessary resources but do not start the game.",
+	"start.arg.offline":                 "Do not access the network. The instance must have been prepared before.",
+	"start.arg.export":                  "Write the prepared launch to a file instead of starting the game",
+	"start.arg.format":                  "Format of the exported launch",
+	"start.arg.tokenenv":                "Read the access token from this environment variable when the exported launch is started",
+	"start.arg.keeptoken":               "Include the access token in the exported launch. By default, it is redacted.",
+	"start.offline.auth":                "Account tokens cannot be refreshed in offline mode. Multiplayer may not work.",
+	"start.arg.opts":                    "Game Options",
+	"start.arg.overrides":               "Configuration Overrides",
+	"start.arg.exportgroup":             "Export",
+	"start.prepared":                    "Game prepared successfully.",
+	"start.exported":                    "Launch exported to %s",
+	"start.processing":                  "Post processors are being run. This may take some time.",
+	"start.java.system":                 "Using Java %d (%s) at %s",
+	"start.java.mismatch":               "The Java executable %s is Java %d, but this version of the game requires Java %d.",
+	"start.launch.downloading":          "Downloading files",
+	"start.launch.downloading.progress": "Downloading files (%d/%d, %s left)",
+	"start.launch.downloading.file":     "Downloading %s",
+	"start.launch.assets":               "Identified %d assets",
+	"start.launch.libraries":            "Identified %d libraries",
+	"start.launch.metadata":             "Version metadata retrieved",
+	"start.launch.jvmargs":              "JVM arguments: %s",
+	"start.launch.gameargs":             "Game arguments: %s",
+	"start.launch.info":                 "Starting main class %q. Game directory is %q.",
+	"start.launch":                      "Launching game as %s",
+	"start.crash.report":                "Crash report: %s",
+	"start.crash.mod":                   "Suspected mod: %s",
 
 	"arg.verbosity": "Increase launcher output verbosity",
 	"arg.dir":       "Root directory for launcher files",
 	"arg.nocolor":   "Disable all color output. The NO_COLOR environment variable is also supported.",
+	"arg.proxy":     "Proxy for all network access. Defaults to the HTTP_PROXY and HTTPS_PROXY environment variables.",
+	"arg.cacert":    "Additional PEM file with root certificates to trust",
 
-	"tip.internet":  "Check your internet connection.",
-	"tip.cache":     "Remote resources were not cached and were unable to be retrieved. Check your Internet connection.",
-	"tip.configure": "Configure this instance with the `instance.toml` file within the instance directory.",
-	"tip.nojvm":     "If a Mojang-provided JVM is not available, you can install it yourself and set the path to the Java executable in the instance configuration.",
-	"tip.noaccount": "To launch in offline mode, use the --username (-u) flag.",
+	"tip.internet":      "Check your internet connection.",
+	"tip.cache":         "Remote resources were not cached and were unable to be retrieved. Check your Internet connection.",
+	"tip.configure":     "Configure this instance with the `instance.toml` file within the instance directory.",
+	"tip.nojvm":         "If a Mojang-provided JVM is not available, you can install it yourself and set the path to the Java executable in the instance configuration, or set it to 'system' to use an installed Java with the required version.",
+	"tip.noaccount":     "To launch in offline mode, use the --username (-u) flag.",
+	"tip.offline":       "Start the instance once without --offline, or with --prepare, to download all necessary files.",
+	"tip.verify":        "Run this command again with --fix to remove invalid files.",
+	"tip.nomodloader":   "Mods can only be installed in instances with a mod loader. Create an instance with --loader to use mods.",
+	"tip.crash":         "Check the crash report and the game log in the logs directory of the instance. If you use mods, try starting the game without the suspected mod.",
+	"tip.hook":          "Check the hooks in the instance.toml file of the instance.",
+	"tip.curseforgekey": "CurseForge modpacks need an API key from https://console.curseforge.com. Set it with --curseforge-key or the CURSEFORGE_API_KEY environment variable, or use --mods-dir with already downloaded mods.",
 
 	"launcher.description": "A minimal command-line Minecraft launcher.",
 	"launcher.license":     "Licensed MIT",
@@ -137,58 +251,172 @@ var de = translations{
 	"delete.arg.id":   "Instanz zum Löschen",
 	"delete.arg.yes":  "Zu allen Fragen automatisch zustimmen.",
 
-	"rename":          "Instanze umbenennen",
-	"rename.complete": "Instanz umbennant.",
-	"rename.arg.id":   "Instanz zum Umbenennen",
-	"rename.arg.new":  "Neuen Name für die Instanz",
-
-	"search":               "Versionen suchen",
-	"search.complete":      "%d Ergebnise gefunden",
-	"search.table.version": "Version",
-	"search.table.type":    "Typ",
-	"search.table.date":    "Veröffentlicht am",
-	"search.table.name":    "Name",
-	"search.arg.query":     "Suchanfrage",
-	"search.arg.kind":      "Suchtyp",
-	"search.arg.reverse":   "Liste umgekehrt anzeigen",
-
-	"start":                    "Instanze starten",
-	"start.arg.id":             "Instanz zum Starten",
-	"start.arg.username":       "Benutzername (Offlinemodus)",
-	"start.arg.server":         "Einem Server beim Spielstart beitreten",
-	"start.arg.world":          "Einer Welt beim Spielstart beitreten ",
-	"start.arg.demo":           "Spiel im Testmodus starten",
-	"start.arg.disablemp":      "Mehrspielermodus deaktivieren",
-	"start.arg.disablechat":    "Chat deaktivieren",
-	"start.arg.width":          "Spielfensterbreite",
-	"start.arg.height":         "Spielfensterhöhe",
-	"start.arg.jvm":            "JVM-Pfad",
-	"start.arg.jvmargs":        "JVM Argumente",
-	"start.arg.minmemory":      "Minimale Arbeitsspeicherauslastung",
-	"start.arg.maxmemory":      "Maximale Arbeitsspeicherauslastung",
-	"start.arg.prepare":        "Alle gebrauchten Spielressourcen herunterladen, aber das Spiel nicht starten.",
-	"start.arg.opts":           "Spieleinstellungen",
-	"start.arg.overrides":      "Konfigurationüberschreibungen",
-	"start.prepared":           "Spiel erfolgreich vorbereitet.",
-	"start.processing":         "Nachbearbeitungen sind jetzt im Gange. Das kann einige Zeit dauern.",
-	"start.launch.downloading": "Dateien herunterladen ...",
-	"start.launch.assets":      "%d Ressourcen identifiziert",
-	"start.launch.libraries":   "%d Bibliotheken identifiziert",
-	"start.launch.metadata":    "Versiondaten heruntergeladen",
-	"start.launch.jvmargs":     "JVM Argumente: %s",
-	"start.launch.gameargs":    "Spielargumente: %s",
-	"start.launch.info":        "Hauptklasse %q wird gestartet. Spielverzeichnis ist %q.",
-	"start.launch":             "Spiel als %s starten ...",
+	"rename":                      "Instanze umbenennen",
+	"rename.complete":             "Instanz umbennant.",
+	"rename.arg.id":               "Instanz zum Umbenennen",
+	"rename.arg.new":              "Neuen Name für die Instanz",
+	"list.arg.sort":               "Instanzen nach Name, zuletzt gespielt oder gesamter Spielzeit sortieren",
+	"list.arg.reverse":            "Reihenfolge der Instanzen umkehren",
+	"list.table.lastplayed":       "Zuletzt gespielt",
+	"list.table.playtime":         "Spielzeit",
+	"list.never":                  "Nie",
+	"history":                     "Vergangene Sitzungen einer Instanz anzeigen",
+	"history.arg.id":              "Instanz, deren Verlauf angezeigt werden soll",
+	"history.none":                "Instanz '%s' wurde noch nicht gespielt.",
+	"history.table.start":         "Gestartet",
+	"history.table.duration":      "Dauer",
+	"history.table.exitcode":      "Exit-Code",
+	"history.table.account":       "Konto",
+	"import":                      "Eine Instanz aus einem Modrinth- (.mrpack) oder CurseForge-Modpack (.zip) oder einer MultiMC/Prism Launcher-Instanz erstellen",
+	"import.complete":             "'%s' mit Minecraft %s (%s%s) importiert",
+	"import.arg.file":             "Zu importierende Modpack-Datei oder MultiMC/Prism Launcher-Instanzverzeichnis",
+	"import.arg.name":             "Name der Instanz. Standardmäßig der Name des Modpacks oder der Instanz.",
+	"import.arg.move":             "Spielverzeichnis einer MultiMC/Prism Launcher-Instanz verschieben statt kopieren",
+	"import.arg.curseforgekey":    "CurseForge-API-Schlüssel zum Herunterladen der Mods von CurseForge-Modpacks",
+	"import.arg.modsdir":          "Verzeichnis, aus dem die Mods von CurseForge-Modpacks stattdessen genommen werden, als <Projekt-ID>/<Datei-ID>/<Datei>",
+	"import.unsupported":          "Nicht importiert: %s",
+	"import.mods.unrecorded":      "Die Mods des Modpacks konnten nicht erfasst werden und lassen sich daher nicht aktualisieren: %s",
+	"export":                      "Eine Instanz als Modrinth-Modpack (.mrpack) exportieren",
+	"export.complete":             "'%s' nach %s exportiert (%d heruntergeladene Dateien, %d Overrides)",
+	"export.arg.id":               "Zu exportierende Instanz",
+	"export.arg.file":             "Zu schreibende Modpack-Datei",
+	"export.arg.name":             "Name des Modpacks. Standardmäßig der Name der Instanz.",
+	"export.arg.version":          "Version des Modpacks",
+	"export.arg.summary":          "Kurze Beschreibung des Modpacks",
+	"export.arg.include":          "Nur Dateien einschließen, die einem Glob relativ zum Instanzverzeichnis entsprechen. Kann wiederholt werden.",
+	"export.arg.exclude":          "Dateien auslassen, die einem Glob relativ zum Instanzverzeichnis entsprechen. Kann wiederholt werden.",
+	"export.arg.nodefaultexclude": "Auch Logs, Absturzberichte, Welten und Screenshots einschließen",
+
+	"search":                "Versionen suchen",
+	"search.complete":       "%d Ergebnise gefunden",
+	"search.table.version":  "Version",
+	"search.table.type":     "Typ",
+	"search.table.date":     "Veröffentlicht am",
+	"search.table.name":     "Name",
+	"search.arg.query":      "Suchanfrage",
+	"search.arg.kind":       "Suchtyp",
+	"search.arg.reverse":    "Liste umgekehrt anzeigen",
+	"cache":                 "Von Instanzen geteilte Dateien verwalten",
+	"cache.usage":           "Speicherbelegung geteilter Dateien anzeigen",
+	"cache.verify":          "Geteilte Dateien anhand ihrer Prüfsummen überprüfen",
+	"cache.prune":           "Geteilte Dateien entfernen, die keine Instanz verwendet",
+	"cache.table.category":  "Kategorie",
+	"cache.table.files":     "Dateien",
+	"cache.table.size":      "Größe",
+	"cache.table.total":     "Gesamt",
+	"cache.verify.running":  "Dateien werden überprüft. Dies kann einige Zeit dauern.",
+	"cache.verify.complete": "Alle Dateien sind gültig.",
+	"cache.verify.invalid":  "Ungültige Datei: %s",
+	"cache.verify.fixed":    "%d ungültige Dateien entfernt. Sie werden bei Bedarf erneut heruntergeladen.",
+	"cache.prune.none":      "Keine unbenutzten Dateien gefunden.",
+	"cache.prune.found":     "%d unbenutzte Dateien gefunden (%s)",
+	"cache.prune.confirm":   "Entfernen? [y/n] ",
+	"cache.prune.complete":  "%s freigegeben",
+	"cache.arg.fix":         "Ungültige Dateien entfernen, damit sie erneut heruntergeladen werden",
+	"cache.arg.dryrun":      "Unbenutzte Dateien nur auflisten, ohne sie zu entfernen",
+	"java":                  "Von Mojang bereitgestellte Java-Laufzeitumgebungen verwalten",
+	"java.list":             "Installierte und verfügbare Java-Laufzeitumgebungen auflisten",
+	"java.install":          "Eine Java-Laufzeitumgebung herunterladen",
+	"java.verify":           "Installierte Java-Laufzeitumgebungen anhand ihrer Manifeste überprüfen und reparieren",
+	"java.remove":           "Eine installierte Java-Laufzeitumgebung entfernen",
+	"java.table.component":  "Komponente",
+	"java.table.version":    "Version",
+	"java.table.released":   "Veröffentlicht am",
+	"java.table.installed":  "Installiert",
+	"java.no":               "Nein",
+	"java.install.complete": "Java-Laufzeitumgebung %s installiert",
+	"java.verify.none":      "Keine Java-Laufzeitumgebungen installiert.",
+	"java.verify.running":   "Java-Laufzeitumgebung %s wird überprüft",
+	"java.verify.invalid":   "Ungültige Laufzeitdatei: %s",
+	"java.verify.complete":  "Alle Dateien von %s sind gültig.",
+	"java.verify.fixed":     "%d Dateien von %s repariert",
+	"java.remove.confirm":   "Java-Laufzeitumgebung '%s' entfernen? Sie wird erneut heruntergeladen, wenn eine Instanz sie benötigt. [y/n] ",
+	"java.remove.complete":  "Java-Laufzeitumgebung %s entfernt",
+	"java.arg.component":    "Java-Laufzeitkomponente, zum Beispiel java-runtime-delta",
+	"java.arg.components":   "Zu überprüfende Java-Laufzeitkomponenten. Falls keine angegeben sind, werden alle installierten überprüft.",
+	"mod":                   "Mods einer Instanz von Modrinth verwalten",
+	"mod.search":            "Modrinth nach Mods durchsuchen, die mit einer Instanz kompatibel sind",
+	"mod.add":               "Mods und ihre Abhängigkeiten installieren",
+	"mod.remove":            "Mods und Abhängigkeiten entfernen, die keine andere Mod benötigt",
+	"mod.list":              "Installierte Mods auflisten",
+	"mod.update":            "Mods auf ihre neueste kompatible Version aktualisieren",
+	"mod.table.slug":        "Slug",
+	"mod.table.author":      "Autor",
+	"mod.table.downloads":   "Downloads",
+	"mod.table.file":        "Datei",
+	"mod.table.dependency":  "Abhängigkeit",
+	"mod.resolved":          "%s %s aufgelöst",
+	"mod.add.complete":      "%s %s hinzugefügt",
+	"mod.remove.complete":   "%s entfernt",
+	"mod.list.none":         "In '%s' sind keine Mods installiert.",
+	"mod.update.none":       "Alle Mods sind aktuell.",
+	"mod.update.complete":   "%s von %s auf %s aktualisiert",
+	"mod.update.skipped":    "%s wurde beibehalten, da es nicht aktualisiert werden konnte: %s",
+	"mod.arg.id":            "Instanz, deren Mods verwaltet werden",
+	"mod.arg.limit":         "Maximale Anzahl an Ergebnissen",
+	"mod.arg.projects":      "Zu installierende Modrinth-Projekte, nach Slug oder ID",
+	"mod.arg.installed":     "Installierte Mods, nach Slug, ID oder Name",
+	"mod.arg.update":        "Zu aktualisierende Mods, nach Slug, ID oder Name. Falls keine angegeben sind, werden alle Mods aktualisiert.",
+
+	"start":                             "Instanze starten",
+	"start.arg.id":                      "Instanz zum Starten",
+	"start.arg.username":                "Benutzername (Offlinemodus)",
+	"start.arg.server":                  "Einem Server beim Spielstart beitreten",
+	"start.arg.world":                   "Einer Welt beim Spielstart beitreten ",
+	"start.arg.demo":                    "Spiel im Testmodus starten",
+	"start.arg.disablemp":               "Mehrspielermodus deaktivieren",
+	"start.arg.disablechat":             "Chat deaktivieren",
+	"start.arg.width":                   "Spielfensterbreite",
+	"start.arg.height":                  "Spielfensterhöhe",
+	"start.arg.jvm":                     "JVM-Pfad",
+	"start.arg.jvmargs":                 "JVM Argumente",
+	"start.arg.minmemory":               "Minimale Arbeitsspeicherauslastung",
+	"start.arg.maxmemory":               "Maximale Arbeitsspeicherauslastung",
+	"start.arg.prepare":                 "Alle gebrauchten Spielressourcen herunterladen, aber das Spiel nicht starten.",
+	"start.arg.offline":                 "Nicht auf das Netzwerk zugreifen. Die Instanz muss vorher vorbereitet worden sein.",
+	"start.arg.export":                  "Vorbereiteten Start in eine Datei schreiben, statt das Spiel zu starten",
+	"start.arg.format":                  "Format des exportierten Starts",
+	"start.arg.tokenenv":                "Zugriffstoken beim Ausführen des exportierten Starts aus dieser Umgebungsvariable lesen",
+	"start.arg.keeptoken":               "Zugriffstoken in den exportierten Start aufnehmen. Standardmäßig wird es entfernt.",
+	"start.offline.auth":                "Konto-Tokens können im Offlinemodus nicht erneuert werden. Mehrspielermodus funktioniert eventuell nicht.",
+	"start.arg.opts":                    "Spieleinstellungen",
+	"start.arg.overrides":               "Konfigurationüberschreibungen",
+	"start.arg.exportgroup":             "Export",
+	"start.prepared":                    "Spiel erfolgreich vorbereitet.",
+	"start.exported":                    "Start nach %s exportiert",
+	"start.processing":                  "Nachbearbeitungen sind jetzt im Gange. Das kann einige Zeit dauern.",
+	"start.java.system":                 "Java %d (%s) in %s wird verwendet",
+	"start.java.mismatch":               "Die Java Datei %s ist Java %d, aber diese Spielversion benötigt Java %d.",
+	"start.launch.downloading":          "Dateien herunterladen ...",
+	"start.launch.downloading.progress": "Dateien herunterladen (%d/%d, noch %s)",
+	"start.launch.downloading.file":     "%s wird heruntergeladen",
+	"start.launch.assets":               "%d Ressourcen identifiziert",
+	"start.launch.libraries":            "%d Bibliotheken identifiziert",
+	"start.launch.metadata":             "Versiondaten heruntergeladen",
+	"start.launch.jvmargs":              "JVM Argumente: %s",
+	"start.launch.gameargs":             "Spielargumente: %s",
+	"start.launch.info":                 "Hauptklasse %q wird gestartet. Spielverzeichnis ist %q.",
+	"start.launch":                      "Spiel als %s starten ...",
+	"start.crash.report":                "Absturzbericht: %s",
+	"start.crash.mod":                   "Vermutete Mod: %s",
 
 	"arg.verbosity": "Gesprächigkeit ändern",
 	"arg.dir":       "Wurzelverzeichnis für Launcherdateien",
 	"arg.nocolor":   "Farben nicht anzeigen. Die NO_COLOR Umgebungsvariable kann auch benutzt werden.",
+	"arg.proxy":     "Proxy für alle Netzwerkzugriffe. Standardmäßig werden die HTTP_PROXY und HTTPS_PROXY Umgebungsvariablen verwendet.",
+	"arg.cacert":    "Zusätzliche PEM-Datei mit vertrauenswürdigen Stammzertifikaten",
 
-	"tip.internet":  "Stell sicher, dass deine Internetverbindung funktioniert.",
-	"tip.cache":     "Onlineressourcen waren nicht im Cache und konnten nicht heruntergeladen werden. Überprüfe deine Internetverbindung.",
-	"tip.configure": "Die Einstellungen dieser Instanz können in der `instance.toml` Datei im Instanzverzeichnis angepasst werden.",
-	"tip.nojvm":     "Falls ein JVM von Mojang nicht verfügbar ist, kannst du es selbst installieren und den Pfad zur Java Datei in der Instanzkonfiguration einstellen.",
-	"tip.noaccount": "Um in Offlinemodus zu starten, verwende den --username (-u) Parameter.",
+	"tip.internet":      "Stell sicher, dass deine Internetverbindung funktioniert.",
+	"tip.cache":         "Onlineressourcen waren nicht im Cache und konnten nicht heruntergeladen werden. Überprüfe deine Internetverbindung.",
+	"tip.configure":     "Die Einstellungen dieser Instanz können in der `instance.toml` Datei im Instanzverzeichnis angepasst werden.",
+	"tip.nojvm":         "Falls ein JVM von Mojang nicht verfügbar ist, kannst du es selbst installieren und den Pfad zur Java Datei in der Instanzkonfiguration einstellen, oder ihn auf 'system' setzen, um ein installiertes Java mit der benötigten Version zu verwenden.",
+	"tip.noaccount":     "Um in Offlinemodus zu starten, verwende den --username (-u) Parameter.",
+	"tip.offline":       "Starte die Instanz einmal ohne --offline, oder mit --prepare, um alle gebrauchten Dateien herunterzuladen.",
+	"tip.verify":        "Führe diesen Befehl mit --fix erneut aus, um ungültige Dateien zu entfernen.",
+	"tip.nomodloader":   "Mods können nur in Instanzen mit einem Modloader installiert werden. Erstelle eine Instanz mit --loader, um Mods zu verwenden.",
+	"tip.crash":         "Sieh dir den Absturzbericht und das Spiellog im logs Verzeichnis der Instanz an. Falls du Mods verwendest, versuche das Spiel ohne die vermutete Mod zu starten.",
+	"tip.hook":          "Überprüfe die Hooks in der instance.toml Datei der Instanz.",
+	"tip.curseforgekey": "CurseForge-Modpacks benötigen einen API-Schlüssel von https://console.curseforge.com. Setze ihn mit --curseforge-key oder der CURSEFORGE_API_KEY Umgebungsvariable, oder verwende --mods-dir mit bereits heruntergeladenen Mods.",
 
 	"launcher.description": "Ein minimalisticher Minecraft Launcher für die Command Line.",
 	"launcher.license":     "MIT-Lizenz",
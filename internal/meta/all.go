@@ -1,6 +1,7 @@
 package meta
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -15,12 +16,23 @@ const (
 	LoaderForge    Loader = "forge"
 )
 
+// ListCachePaths returns the paths of all cached version lists, such as the version manifest.
+func ListCachePaths() []string {
+	return []string{
+		versionManifestPath(),
+		javaManifestListPath(),
+		Fabric.versionsPath(),
+		Quilt.versionsPath(),
+		forgePromotionsPath(),
+	}
+}
+
 // FetchAllVersionMeta returns a VersionMeta containing both information for the base game, and specified mod loader.
-func FetchAllVersionMeta(loader Loader, gameVersion string, loaderVersion string) (VersionMeta, error) {
+func FetchAllVersionMeta(ctx context.Context, loader Loader, gameVersion string, loaderVersion string) (VersionMeta, error) {
 	var loaderMeta VersionMeta
 	var err error
 
-	version, err := FetchVersionMeta(gameVersion)
+	version, err := FetchVersionMeta(ctx, gameVersion)
 	if err != nil {
 		return VersionMeta{}, fmt.Errorf("retrieve version metadata: %w", err)
 	}
@@ -31,29 +43,29 @@ func FetchAllVersionMeta(loader Loader, gameVersion string, loaderVersion string
 		if loader == LoaderQuilt {
 			api = Quilt
 		}
-		loaderMeta, err = api.FetchMeta(version.ID, loaderVersion)
+		loaderMeta, err = api.FetchMeta(ctx, version.ID, loaderVersion)
 		if err != nil {
 			return VersionMeta{}, fmt.Errorf("retrieve Fabric/Quilt metadata: %w", err)
 		}
 	case LoaderNeoForge:
 		if loaderVersion == "latest" {
-			loaderVersion, err = FetchNeoforgeVersion(version.ID)
+			loaderVersion, err = FetchNeoforgeVersion(ctx, version.ID)
 			if err != nil {
 				return VersionMeta{}, fmt.Errorf("retrieve NeoForge version: %w", err)
 			}
 		}
-		loaderMeta, _, err = Neoforge.FetchMeta(loaderVersion)
+		loaderMeta, _, err = Neoforge.FetchMeta(ctx, loaderVersion)
 		if err != nil {
 			return VersionMeta{}, fmt.Errorf("retrieve NeoForge metadata: %w", err)
 		}
 	case LoaderForge:
 		if loaderVersion == "latest" {
-			loaderVersion, err = FetchForgeVersion(version.ID)
+			loaderVersion, err = FetchForgeVersion(ctx, version.ID)
 			if err != nil {
 				return VersionMeta{}, fmt.Errorf("retrieve Forge version: %w", err)
 			}
 		}
-		loaderMeta, _, err = Forge.FetchMeta(loaderVersion)
+		loaderMeta, _, err = Forge.FetchMeta(ctx, loaderVersion)
 		if err != nil {
 			return VersionMeta{}, fmt.Errorf("retrieve Forge metadata: %w", err)
 		}
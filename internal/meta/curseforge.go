@@ -0,0 +1,85 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/telecter/cmd-launcher/internal/network"
+)
+
+// CurseForgeURL is the base URL of the CurseForge v1 API.
+const CurseForgeURL = "https://api.curseforge.com/v1"
+
+var (
+	// ErrCurseForgeNotFound is returned when a CurseForge project or file does not exist.
+	ErrCurseForgeNotFound = errors.New("not found on CurseForge")
+	// ErrCurseForgeUnauthorized is returned when the CurseForge API key is missing or invalid.
+	ErrCurseForgeUnauthorized = errors.New("invalid CurseForge API key")
+)
+
+// CurseForge hash algorithms.
+const (
+	CurseForgeSha1 = 1
+	CurseForgeMd5  = 2
+)
+
+// A CurseForgeFile is a file of a CurseForge project.
+type CurseForgeFile struct {
+	ID          int    `json:"id"`
+	ModID       int    `json:"modId"`
+	DisplayName string `json:"displayName"`
+	FileName    string `json:"fileName"`
+	// DownloadURL is empty if the author does not allow third-party downloads.
+	DownloadURL string `json:"downloadUrl"`
+	FileLength  int64  `json:"fileLength"`
+	Hashes      []struct {
+		Value string `json:"value"`
+		Algo  int    `json:"algo"`
+	} `json:"hashes"`
+}
+
+// Sha1 returns the SHA-1 hash of the file, or an empty string if it is unknown.
+func (file CurseForgeFile) Sha1() string {
+	for _, hash := range file.Hashes {
+		if hash.Algo == CurseForgeSha1 {
+			return hash.Value
+		}
+	}
+	return ""
+}
+
+// FetchCurseForgeFile retrieves a file of a CurseForge project using the API key.
+func FetchCurseForgeFile(ctx context.Context, apiKey string, projectID, fileID int) (CurseForgeFile, error) {
+	req, err := network.NewRequest(ctx, http.MethodGet, fmt.Sprintf("%s/mods/%d/files/%d", CurseForgeURL, projectID, fileID), nil)
+	if err != nil {
+		return CurseForgeFile{}, err
+	}
+	req.Header.Set("x-api-key", apiKey)
+	resp, err := network.Do(req)
+	if err != nil {
+		return CurseForgeFile{}, err
+	}
+	defer resp.Body.Close()
+	if err := network.CheckResponse(resp); err != nil {
+		var statusErr *network.HTTPStatusError
+		if errors.As(err, &statusErr) {
+			switch statusErr.StatusCode {
+			case http.StatusNotFound:
+				err = ErrCurseForgeNotFound
+			case http.StatusUnauthorized, http.StatusForbidden:
+				err = ErrCurseForgeUnauthorized
+			}
+		}
+		return CurseForgeFile{}, fmt.Errorf("fetch file %d of project %d: %w", fileID, projectID, err)
+	}
+	var result struct {
+		Data CurseForgeFile `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return CurseForgeFile{}, fmt.Errorf("parse response: %w", err)
+	}
+	return result.Data, nil
+}
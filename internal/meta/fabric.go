@@ -1,6 +1,7 @@
 package meta
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -33,15 +34,25 @@ var Quilt = fabricAPI{
 	url:  "https://meta.quiltmc.org/v3",
 }
 
+func (api fabricAPI) versionsPath() string {
+	return filepath.Join(env.CachesDir, api.name, "versions.json")
+}
+
+// MetaPath returns the path of the cached version metadata for the specified game and loader version.
+func (api fabricAPI) MetaPath(gameVersion, loaderVersion string) string {
+	return filepath.Join(env.CachesDir, api.name, loaderVersion+"-"+gameVersion+".json")
+}
+
 // FetchVersions retrieves a list of all versions of Fabric.
-func (api fabricAPI) FetchVersions() (FabricVersionList, error) {
+func (api fabricAPI) FetchVersions(ctx context.Context) (FabricVersionList, error) {
 	cache := network.Cache[FabricVersionList]{
-		Path:        filepath.Join(env.CachesDir, api.name, "versions.json"),
+		Path:        api.versionsPath(),
 		URL:         fmt.Sprintf("%s/versions/loader", api.url),
-		AlwaysFetch: true,
+		AlwaysFetch: ListMaxAge <= 0,
+		MaxAge:      ListMaxAge,
 	}
 	var versions FabricVersionList
-	if err := cache.Get(&versions); err != nil {
+	if err := cache.GetContext(ctx, &versions); err != nil {
 		return nil, err
 	}
 
@@ -51,21 +62,21 @@ func (api fabricAPI) FetchVersions() (FabricVersionList, error) {
 // FetchMeta retrieves version metadata for the specified game and loader version of Fabric.
 //
 // Besides normal version identifiers, loaderVersion can also be "latest".
-func (api fabricAPI) FetchMeta(gameVersion, loaderVersion string) (VersionMeta, error) {
+func (api fabricAPI) FetchMeta(ctx context.Context, gameVersion, loaderVersion string) (VersionMeta, error) {
 	if loaderVersion == "latest" {
-		versions, err := api.FetchVersions()
+		versions, err := api.FetchVersions(ctx)
 		if err != nil {
 			return VersionMeta{}, fmt.Errorf("fetch versions: %w", err)
 		}
 		loaderVersion = versions[0].Version
 	}
 	cache := network.Cache[VersionMeta]{
-		Path: filepath.Join(env.CachesDir, api.name, loaderVersion+"-"+gameVersion+".json"),
+		Path: api.MetaPath(gameVersion, loaderVersion),
 		URL:  fmt.Sprintf("%s/versions/loader/%s/%s/profile/json", api.url, gameVersion, loaderVersion),
 	}
 
 	var fabricMeta VersionMeta
-	if err := cache.Get(&fabricMeta); err != nil {
+	if err := cache.GetContext(ctx, &fabricMeta); err != nil {
 		var statusErr *network.HTTPStatusError
 		if errors.As(err, &statusErr) && (statusErr.StatusCode == 400 || statusErr.StatusCode == 404) {
 			return VersionMeta{}, fmt.Errorf("invalid or unsuitable game/Fabric version")
@@ -4,11 +4,11 @@ import (
 	"archive/zip"
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path"
 	"path/filepath"
@@ -51,7 +51,7 @@ type ForgeProcessor struct {
 }
 
 // FetchNeoforgeVersion retrieves the best NeoForge loader version for the specified game version.
-func FetchNeoforgeVersion(gameVersion string) (string, error) {
+func FetchNeoforgeVersion(ctx context.Context, gameVersion string) (string, error) {
 	parts := strings.Split(gameVersion, ".")
 	var url string
 	if len(parts) < 2 {
@@ -65,7 +65,7 @@ func FetchNeoforgeVersion(gameVersion string) (string, error) {
 		url = fmt.Sprintf("https://maven.neoforged.net/api/maven/latest/version/releases/net/neoforged/neoforge?filter=%s", end)
 	}
 
-	resp, err := http.Get(url)
+	resp, err := network.Get(ctx, url)
 	if err != nil {
 		return "", err
 	}
@@ -91,51 +91,51 @@ func FetchNeoforgeVersion(gameVersion string) (string, error) {
 	return version, nil
 }
 
+func forgePromotionsPath() string {
+	return filepath.Join(env.CachesDir, "forge", "promotions_slim.json")
+}
+
 // FetchForgePromotions retrieves a map of Minecraft versions to their respective recommended Forge versions.
-func FetchForgePromotions() (*orderedmap.OrderedMap, error) {
-	resp, err := http.Get("https://files.minecraftforge.net/net/minecraftforge/forge/promotions_slim.json")
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if err := network.CheckResponse(resp); err != nil {
-		return nil, err
-	}
-	body, _ := io.ReadAll(resp.Body)
-	var data struct {
-		Promos orderedmap.OrderedMap `json:"promos"`
+func FetchForgePromotions(ctx context.Context) (*orderedmap.OrderedMap, error) {
+	cache := network.Cache[orderedmap.OrderedMap]{
+		Path:        forgePromotionsPath(),
+		URL:         "https://files.minecraftforge.net/net/minecraftforge/forge/promotions_slim.json",
+		AlwaysFetch: ListMaxAge <= 0,
+		MaxAge:      ListMaxAge,
+		Unmarshaler: func(data []byte, v any) error {
+			var promotions struct {
+				Promos orderedmap.OrderedMap `json:"promos"`
+			}
+			if err := json.Unmarshal(data, &promotions); err != nil {
+				return err
+			}
+			*v.(*orderedmap.OrderedMap) = promotions.Promos
+			return nil
+		},
 	}
-	if err := json.Unmarshal(body, &data); err != nil {
+	var promos orderedmap.OrderedMap
+	if err := cache.GetContext(ctx, &promos); err != nil {
 		return nil, fmt.Errorf("read promoted versions: %w", err)
 	}
-	return &data.Promos, nil
+	return &promos, nil
 }
 
 // FetchForgeVersion retrieves the best Forge loader version for the specified game version.
-func FetchForgeVersion(gameVersion string) (string, error) {
-	type response struct {
-		Promos map[string]string `json:"promos"`
-	}
-	resp, err := http.Get("https://files.minecraftforge.net/net/minecraftforge/forge/promotions_slim.json")
+func FetchForgeVersion(ctx context.Context, gameVersion string) (string, error) {
+	promos, err := FetchForgePromotions(ctx)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-	if err := network.CheckResponse(resp); err != nil {
-		return "", err
-	}
-	body, _ := io.ReadAll(resp.Body)
-	var data response
-	if err := json.Unmarshal(body, &data); err != nil {
-		return "", fmt.Errorf("read promoted versions: %w", err)
-	}
 
-	version, ok := data.Promos[gameVersion+"-latest"]
+	version, ok := promos.Get(gameVersion + "-latest")
 	if !ok {
 		return "", fmt.Errorf("no version found for specified game version")
 	}
-	return gameVersion + "-" + version, nil
-
+	s, ok := version.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid promoted version for %s: %v", gameVersion, version)
+	}
+	return gameVersion + "-" + s, nil
 }
 
 type forge struct {
@@ -153,13 +153,18 @@ var Neoforge = forge{
 	},
 }
 
+// InstallerPath returns the path of the cached installer for the specified version.
+func (forge forge) InstallerPath(version string) string {
+	return filepath.Join(env.CachesDir, "forge", path.Base(forge.url(version)))
+}
+
 // FetchInstaller fetchs the Forge installer ZIP file and returns its contents.
-func (forge forge) FetchInstaller(version string) (map[string]*zip.File, error) {
+func (forge forge) FetchInstaller(ctx context.Context, version string) (map[string]*zip.File, error) {
 	url := forge.url(version)
-	path := filepath.Join(env.CachesDir, "forge", path.Base(url))
+	path := forge.InstallerPath(version)
 
 	if _, err := os.Stat(path); err != nil {
-		err := network.DownloadFile(network.DownloadEntry{
+		err := network.DownloadFileContext(ctx, network.DownloadEntry{
 			URL:  url,
 			Path: path,
 		})
@@ -191,8 +196,8 @@ func (forge forge) FetchInstaller(version string) (map[string]*zip.File, error)
 }
 
 // FetchMeta retrieves the Forge version.json (version meta) and install_profile.json from the installer ZIP.
-func (forge forge) FetchMeta(version string) (VersionMeta, ForgeInstallProfile, error) {
-	files, err := forge.FetchInstaller(version)
+func (forge forge) FetchMeta(ctx context.Context, version string) (VersionMeta, ForgeInstallProfile, error) {
+	files, err := forge.FetchInstaller(ctx, version)
 
 	if err != nil {
 		return VersionMeta{}, ForgeInstallProfile{}, fmt.Errorf("fetch installer: %w", err)
@@ -248,12 +253,12 @@ func (forge forge) FetchMeta(version string) (VersionMeta, ForgeInstallProfile,
 }
 
 // FetchPostProcessors retrieves arguments to run Forge's post processors for the specified game version.
-func (forge forge) FetchPostProcessors(gameVersion, version string) ([]ForgeProcessor, error) {
-	files, err := forge.FetchInstaller(version)
+func (forge forge) FetchPostProcessors(ctx context.Context, gameVersion, version string) ([]ForgeProcessor, error) {
+	files, err := forge.FetchInstaller(ctx, version)
 	if err != nil {
 		return nil, fmt.Errorf("fetch installer: %w", err)
 	}
-	_, profile, err := forge.FetchMeta(version)
+	_, profile, err := forge.FetchMeta(ctx, version)
 	if err != nil {
 		return nil, fmt.Errorf("retrieve metadata: %w", err)
 	}
@@ -315,7 +320,7 @@ func (forge forge) FetchPostProcessors(gameVersion, version string) ([]ForgeProc
 
 	variables["SIDE"] = "client"
 
-	versionMeta, err := FetchVersionMeta(gameVersion)
+	versionMeta, err := FetchVersionMeta(ctx, gameVersion)
 	if err != nil {
 		return nil, fmt.Errorf("retrieve version metadata: %w", err)
 	}
@@ -1,10 +1,10 @@
 package meta
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -90,7 +90,7 @@ func NewLibrarySpecifier(s string) (LibrarySpecifier, error) {
 }
 
 // FetchMavenLibrary returns library metadata for the specified name and path in the Maven repository.
-func FetchMavenLibrary(specifier LibrarySpecifier) (Library, error) {
+func FetchMavenLibrary(ctx context.Context, specifier LibrarySpecifier) (Library, error) {
 	url, _ := url.JoinPath(MavenRepoURL, specifier.Path())
 	path := specifier.Path()
 
@@ -99,7 +99,7 @@ func FetchMavenLibrary(specifier LibrarySpecifier) (Library, error) {
 	sum, err := os.ReadFile(sumPath)
 
 	if err != nil {
-		resp, err := http.Get(url + ".sha1")
+		resp, err := network.Get(ctx, url+".sha1")
 		if err != nil {
 			return Library{}, err
 		}
@@ -1,15 +1,18 @@
 package meta
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,6 +27,10 @@ const (
 	MinecraftLibrariesURL = "https://libraries.minecraft.net"
 )
 
+// ListMaxAge is how long version lists are used before they are revalidated with the server.
+// If it is 0, they are revalidated every time.
+var ListMaxAge = 10 * time.Minute
+
 // A VersionManifest is a list of all Minecraft versions.
 type VersionManifest struct {
 	Latest struct {
@@ -103,6 +110,7 @@ func (manifest JavaManifest) DownloadEntries(runtimeName string) (entries []netw
 			}
 			entries = append(entries, network.DownloadEntry{
 				Sha1:     file.Downloads.Raw.Sha1,
+				Size:     int64(file.Downloads.Raw.Size),
 				Path:     path,
 				URL:      file.Downloads.Raw.URL,
 				FileMode: mode,
@@ -115,8 +123,8 @@ func (manifest JavaManifest) DownloadEntries(runtimeName string) (entries []netw
 // A VersionMeta is metadata of the libraries, assets, and other data needed to start a Minecraft version.
 type VersionMeta struct {
 	Arguments struct {
-		Game []any `json:"game"`
-		Jvm  []any `json:"jvm"`
+		Game []Argument `json:"game"`
+		Jvm  []Argument `json:"jvm"`
 	} `json:"arguments"`
 	AssetIndex struct {
 		ID        string `json:"id"`
@@ -169,6 +177,7 @@ type VersionMeta struct {
 		} `json:"client"`
 	} `json:"logging"`
 	MainClass              string `json:"mainClass"`
+	MinecraftArguments     string `json:"minecraftArguments,omitempty"` // Game arguments of versions before 1.13
 	MinimumLauncherVersion int    `json:"minimumLauncherVersion"`
 	ReleaseTime            string `json:"releaseTime"`
 	Time                   string `json:"time"`
@@ -219,6 +228,7 @@ func (artifact Artifact) DownloadEntry() network.DownloadEntry {
 		URL:  artifact.URL,
 		Path: artifact.RuntimePath(),
 		Sha1: artifact.Sha1,
+		Size: int64(artifact.Size),
 	}
 }
 
@@ -227,6 +237,7 @@ type Library struct {
 	Artifact        Artifact
 	Natives         []Library
 	Specifier       LibrarySpecifier
+	Rules           Rules // Rules which decide whether the library is used on a system
 	ShouldInstall   bool
 	SkipOnClasspath bool
 }
@@ -238,12 +249,7 @@ func (l *Library) UnmarshalJSON(b []byte) error {
 			Artifact    Artifact            `json:"artifact"`
 			Classifiers map[string]Artifact `json:"classifiers"`
 		} `json:"downloads"`
-		Rules []struct {
-			Action string `json:"action"`
-			Os     struct {
-				Name string `json:"name"`
-			} `json:"os"`
-		} `json:"rules,omitempty"`
+		Rules   Rules             `json:"rules,omitempty"`
 		Natives map[string]string `json:"natives,omitempty"`
 
 		// fabric
@@ -268,10 +274,15 @@ func (l *Library) UnmarshalJSON(b []byte) error {
 	} else {
 		l.Artifact = data.Downloads.Artifact
 		var classifiers []string
+		ruleEnv := CurrentRuleEnv(nil)
 		for os, native := range data.Natives {
-			os = strings.ReplaceAll(os, "osx", "darwin")
-			if os == runtime.GOOS {
-				classifiers = append(classifiers, native)
+			if os == ruleEnv.OS {
+				// Legacy natives for Windows are split by word size
+				bits := "64"
+				if strconv.IntSize == 32 {
+					bits = "32"
+				}
+				classifiers = append(classifiers, strings.ReplaceAll(native, "${arch}", bits))
 			}
 		}
 		for _, classifier := range classifiers {
@@ -287,32 +298,42 @@ func (l *Library) UnmarshalJSON(b []byte) error {
 				ShouldInstall: true,
 			})
 		}
-		if len(data.Rules) > 0 {
-			rule := data.Rules[0]
-			os := strings.ReplaceAll(rule.Os.Name, "osx", "darwin")
-			if rule.Action == "allow" && (os == runtime.GOOS || os == "") {
-				l.ShouldInstall = true
-			}
-		} else {
-			l.ShouldInstall = true
-		}
+		l.Rules = data.Rules
+		l.ShouldInstall = data.Rules.Allows(ruleEnv)
 	}
 	return nil
 }
 
 // An AssetIndex contains a map of asset objects and their names.
+//
+// Versions before 1.7.3 expect assets by their names instead of their hashes. For their indexes, Virtual or
+// MapToResources is set, and Reconstruct has to be used to create the layout they expect.
 type AssetIndex struct {
-	Objects map[string]struct {
+	Virtual        bool `json:"virtual,omitempty"`          // Assets are expected in "assets/virtual/<id>"
+	MapToResources bool `json:"map_to_resources,omitempty"` // Assets are expected in the "resources" directory of the game
+	Objects        map[string]struct {
 		Hash string `json:"hash"`
 		Size int    `json:"size"`
 	} `json:"objects"`
 }
 
+// AssetObjectPath returns the path of the asset object with the specified hash.
+func AssetObjectPath(hash string) string {
+	return filepath.Join(env.AssetsDir, "objects", hash[:2], hash)
+}
+
 // DownloadEntries returns a list of download entries to any undownloaded assets in the index.
+//
+// Objects with the same hash are stored only once, so only one entry is returned for them.
 func (index AssetIndex) DownloadEntries() (entries []network.DownloadEntry) {
+	seen := make(map[string]bool)
 	for _, object := range index.Objects {
+		if seen[object.Hash] {
+			continue
+		}
+		seen[object.Hash] = true
 		url, _ := url.JoinPath(MinecraftResourcesURL, object.Hash[:2], object.Hash)
-		path := filepath.Join(env.AssetsDir, "objects", object.Hash[:2], object.Hash)
+		path := AssetObjectPath(object.Hash)
 		data, err := os.ReadFile(path)
 		if err == nil {
 			sum := sha1.Sum(data)
@@ -324,21 +345,88 @@ func (index AssetIndex) DownloadEntries() (entries []network.DownloadEntry) {
 			URL:  url,
 			Path: path,
 			Sha1: object.Hash,
+			Size: int64(object.Size),
 		})
 	}
 	return entries
 }
 
+// Reconstruct places every object of the index at its name within dir.
+//
+// Objects are hardlinked from the object store if possible, and copied otherwise. Files which already exist
+// with the right size are kept.
+func (index AssetIndex) Reconstruct(dir string) error {
+	for name, object := range index.Objects {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if info, err := os.Stat(path); err == nil && info.Size() == int64(object.Size) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("create directory for asset %q: %w", name, err)
+		}
+		os.Remove(path)
+		src := AssetObjectPath(object.Hash)
+		if err := os.Link(src, path); err != nil {
+			if err := copyFile(src, path); err != nil {
+				return fmt.Errorf("copy asset %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// copyFile copies the file at src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func versionManifestPath() string {
+	return filepath.Join(env.CachesDir, "minecraft", "version_manifest.json")
+}
+
+// VersionMetaPath returns the path of the cached version metadata for the specified version.
+func VersionMetaPath(id string) string {
+	return filepath.Join(env.CachesDir, "minecraft", id+".json")
+}
+
+// AssetIndexPath returns the path of the asset index with the specified ID.
+func AssetIndexPath(id string) string {
+	return filepath.Join(env.AssetsDir, "indexes", id+".json")
+}
+
+func javaManifestListPath() string {
+	return filepath.Join(env.CachesDir, "minecraft", "java_all.json")
+}
+
+// JavaManifestPath returns the path of the cached manifest for the specified Mojang-provided Java runtime.
+func JavaManifestPath(name string) string {
+	return filepath.Join(env.CachesDir, "minecraft", name+".json")
+}
+
 // FetchVersionManifest retrieves the Mojang version manifest which lists all game versions.
-func FetchVersionManifest() (VersionManifest, error) {
+func FetchVersionManifest(ctx context.Context) (VersionManifest, error) {
 	cache := network.Cache[VersionManifest]{
-		Path:        filepath.Join(env.CachesDir, "minecraft", "version_manifest.json"),
+		Path:        versionManifestPath(),
 		URL:         VersionManifestURL,
-		AlwaysFetch: true,
+		AlwaysFetch: ListMaxAge <= 0,
+		MaxAge:      ListMaxAge,
 	}
 
 	var manifest VersionManifest
-	if err := cache.Get(&manifest); err != nil {
+	if err := cache.GetContext(ctx, &manifest); err != nil {
 		return VersionManifest{}, err
 	}
 
@@ -348,8 +436,8 @@ func FetchVersionManifest() (VersionManifest, error) {
 // FetchVersionMeta retrieves the version metadata for a specified version from the version manifest.
 //
 // Besides normal version identifiers, "release" and "snapshot" are also accepted IDs.
-func FetchVersionMeta(id string) (VersionMeta, error) {
-	manifest, err := FetchVersionManifest()
+func FetchVersionMeta(ctx context.Context, id string) (VersionMeta, error) {
+	manifest, err := FetchVersionManifest(ctx)
 	if err != nil {
 		return VersionMeta{}, fmt.Errorf("retrieve version manifest: %w", err)
 	}
@@ -362,12 +450,12 @@ func FetchVersionMeta(id string) (VersionMeta, error) {
 	for _, v := range manifest.Versions {
 		if v.ID == id {
 			cache := network.Cache[VersionMeta]{
-				Path:       filepath.Join(env.CachesDir, "minecraft", v.ID+".json"),
+				Path:       VersionMetaPath(v.ID),
 				URL:        v.URL,
 				RemoteSha1: v.Sha1,
 			}
 			var versionMeta VersionMeta
-			if err := cache.Get(&versionMeta); err != nil {
+			if err := cache.GetContext(ctx, &versionMeta); err != nil {
 				return VersionMeta{}, err
 			}
 			return versionMeta, nil
@@ -377,9 +465,14 @@ func FetchVersionMeta(id string) (VersionMeta, error) {
 }
 
 // MergeVersionMeta takes two instances of VersionMeta and merges w into v
+//
+// Arguments of w are added to those of v, except for minecraftArguments, which replace those of v.
 func MergeVersionMeta(v, w VersionMeta) VersionMeta {
-	v.Arguments.Jvm = w.Arguments.Jvm
-	v.Arguments.Game = w.Arguments.Game
+	v.Arguments.Jvm = append(v.Arguments.Jvm, w.Arguments.Jvm...)
+	v.Arguments.Game = append(v.Arguments.Game, w.Arguments.Game...)
+	if w.MinecraftArguments != "" {
+		v.MinecraftArguments = w.MinecraftArguments
+	}
 
 	m := make(map[string]int)
 	for _, library := range w.Libraries {
@@ -404,45 +497,65 @@ func MergeVersionMeta(v, w VersionMeta) VersionMeta {
 }
 
 // DownloadAssetIndex retrieves the asset index for the specified version.
-func DownloadAssetIndex(versionMeta VersionMeta) (AssetIndex, error) {
+func DownloadAssetIndex(ctx context.Context, versionMeta VersionMeta) (AssetIndex, error) {
 	cache := network.Cache[AssetIndex]{
-		Path:       filepath.Join(env.AssetsDir, "indexes", versionMeta.AssetIndex.ID+".json"),
+		Path:       AssetIndexPath(versionMeta.AssetIndex.ID),
 		URL:        versionMeta.AssetIndex.URL,
 		RemoteSha1: versionMeta.AssetIndex.Sha1,
 	}
 
 	var assetIndex AssetIndex
-	if err := cache.Get(&assetIndex); err != nil {
+	if err := cache.GetContext(ctx, &assetIndex); err != nil {
 		return AssetIndex{}, err
 	}
 	return assetIndex, nil
 }
 
+// LoggingConfigPath returns the path of the cached log4j configuration with the specified ID.
+func LoggingConfigPath(id string) string {
+	return filepath.Join(env.CachesDir, "minecraft", "log_configs", id)
+}
+
+// LoggingDownloadEntries returns a list of download entries to the log4j configuration of the version,
+// if it has one and it is not downloaded yet.
+func (versionMeta VersionMeta) LoggingDownloadEntries() []network.DownloadEntry {
+	file := versionMeta.Logging.Client.File
+	if file.URL == "" {
+		return nil
+	}
+	path := LoggingConfigPath(file.ID)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		sum := sha1.Sum(data)
+		if file.Sha1 == hex.EncodeToString(sum[:]) {
+			return nil
+		}
+	}
+	return []network.DownloadEntry{{
+		URL:  file.URL,
+		Path: path,
+		Sha1: file.Sha1,
+		Size: int64(file.Size),
+	}}
+}
+
 // FetchJavaManifestList retrieves the list of Mojang-provided Java runtimes.
-func FetchJavaManifestList() (JavaManifestList, error) {
+func FetchJavaManifestList(ctx context.Context) (JavaManifestList, error) {
 	cache := network.Cache[JavaManifestList]{
-		Path: filepath.Join(env.CachesDir, "minecraft", "java_all.json"),
-		URL:  JavaRuntimesURL,
+		Path:        javaManifestListPath(),
+		URL:         JavaRuntimesURL,
+		AlwaysFetch: ListMaxAge <= 0,
+		MaxAge:      ListMaxAge,
 	}
 	var list JavaManifestList
-	if err := cache.Get(&list); err != nil {
+	if err := cache.GetContext(ctx, &list); err != nil {
 		return JavaManifestList{}, err
 	}
 	return list, nil
 }
 
-var ErrJavaBadSystem = errors.New("system is unsupported")
-var ErrJavaNoVersion = errors.New("required version unavailable for this system")
-
-// FetchJavaManifest retrieves the manifest for the specified Mojang-provided Java runtime.
-//
-// It contains information, among other things, about what files to install for the runtime.
-func FetchJavaManifest(name string) (JavaManifest, error) {
-	list, err := FetchJavaManifestList()
-	if err != nil {
-		return JavaManifest{}, fmt.Errorf("retrieve java manifest list: %w", err)
-	}
-
+// JavaPlatform returns the name of the current system in the list of Mojang-provided Java runtimes, such as "linux" or "mac-os-arm64".
+func JavaPlatform() string {
 	os := strings.ReplaceAll(runtime.GOOS, "darwin", "mac-os")
 	arch := strings.ReplaceAll(runtime.GOARCH, "386", "i386")
 
@@ -453,7 +566,22 @@ func FetchJavaManifest(name string) (JavaManifest, error) {
 	if arch != "amd64" {
 		os = os + "-" + arch
 	}
+	return os
+}
+
+var ErrJavaBadSystem = errors.New("system is unsupported")
+var ErrJavaNoVersion = errors.New("required version unavailable for this system")
+
+// FetchJavaManifest retrieves the manifest for the specified Mojang-provided Java runtime.
+//
+// It contains information, among other things, about what files to install for the runtime.
+func FetchJavaManifest(ctx context.Context, name string) (JavaManifest, error) {
+	list, err := FetchJavaManifestList(ctx)
+	if err != nil {
+		return JavaManifest{}, fmt.Errorf("retrieve java manifest list: %w", err)
+	}
 
+	os := JavaPlatform()
 	_, ok := list[os]
 	if !ok {
 		return JavaManifest{}, ErrJavaBadSystem
@@ -470,12 +598,13 @@ func FetchJavaManifest(name string) (JavaManifest, error) {
 	ref := list[os][name][0].Manifest
 
 	cache := network.Cache[JavaManifest]{
-		Path: filepath.Join(env.CachesDir, "minecraft", name+".json"),
-		URL:  ref.URL,
+		Path:       JavaManifestPath(name),
+		URL:        ref.URL,
+		RemoteSha1: ref.Sha1,
 	}
 
 	var manifest JavaManifest
-	if err := cache.Get(&manifest); err != nil {
+	if err := cache.GetContext(ctx, &manifest); err != nil {
 		return JavaManifest{}, err
 	}
 
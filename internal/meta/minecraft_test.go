@@ -0,0 +1,88 @@
+package meta_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/telecter/cmd-launcher/internal/meta"
+	env "github.com/telecter/cmd-launcher/pkg"
+)
+
+func TestAssetIndex_Reconstruct(t *testing.T) {
+	if err := env.SetDirs(t.TempDir()); err != nil {
+		t.Fatalf("unexpected error setting directories: %s", err)
+	}
+	objects := map[string]string{
+		"0f8d60e5d4a4b8d0c5a7b6f0f3a9e1c2b4d6e8f0": "sound",
+		"a1b2c3d4e5f60718293a4b5c6d7e8f9012345678": "texture",
+	}
+	for hash, content := range objects {
+		path := meta.AssetObjectPath(hash)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unexpected error creating object directory: %s", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error writing object: %s", err)
+		}
+	}
+
+	var index meta.AssetIndex
+	data := `{
+		"virtual": true,
+		"objects": {
+			"sound/random/click.ogg": {"hash": "0f8d60e5d4a4b8d0c5a7b6f0f3a9e1c2b4d6e8f0", "size": 5},
+			"icons/icon_16x16.png": {"hash": "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678", "size": 7}
+		}
+	}`
+	if err := json.Unmarshal([]byte(data), &index); err != nil {
+		t.Fatalf("unexpected error parsing asset index: %s", err)
+	}
+	if !index.Virtual || index.MapToResources {
+		t.Fatalf("wanted virtual index; got virtual=%t, map_to_resources=%t", index.Virtual, index.MapToResources)
+	}
+
+	dir := filepath.Join(env.AssetsDir, "virtual", "legacy")
+	// Running twice must keep existing files
+	for range 2 {
+		if err := index.Reconstruct(dir); err != nil {
+			t.Fatalf("unexpected error reconstructing assets: %s", err)
+		}
+	}
+	for name, object := range index.Objects {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("wanted asset %q to exist: %s", name, err)
+		}
+		if string(data) != objects[object.Hash] {
+			t.Errorf("wanted asset %q to contain %q; got %q", name, objects[object.Hash], data)
+		}
+		info, _ := os.Stat(path)
+		objectInfo, _ := os.Stat(meta.AssetObjectPath(object.Hash))
+		if !os.SameFile(info, objectInfo) {
+			t.Errorf("wanted asset %q to be a hardlink to its object", name)
+		}
+	}
+}
+
+func TestAssetIndex_DownloadEntries(t *testing.T) {
+	if err := env.SetDirs(t.TempDir()); err != nil {
+		t.Fatalf("unexpected error setting directories: %s", err)
+	}
+	var index meta.AssetIndex
+	data := `{
+		"objects": {
+			"minecraft/sounds/a.ogg": {"hash": "0f8d60e5d4a4b8d0c5a7b6f0f3a9e1c2b4d6e8f0", "size": 5},
+			"minecraft/sounds/b.ogg": {"hash": "0f8d60e5d4a4b8d0c5a7b6f0f3a9e1c2b4d6e8f0", "size": 5},
+			"icons/icon_16x16.png": {"hash": "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678", "size": 7}
+		}
+	}`
+	if err := json.Unmarshal([]byte(data), &index); err != nil {
+		t.Fatalf("unexpected error parsing asset index: %s", err)
+	}
+	if entries := index.DownloadEntries(); len(entries) != 2 {
+		t.Errorf("wanted one entry per object hash (2); got %d", len(entries))
+	}
+}
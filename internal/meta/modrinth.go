@@ -0,0 +1,199 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/telecter/cmd-launcher/internal/network"
+)
+
+// ModrinthURL is the base URL of the Modrinth v2 API.
+const ModrinthURL = "https://api.modrinth.com/v2"
+
+// ErrModrinthNotFound is returned when a Modrinth project or version does not exist.
+var ErrModrinthNotFound = errors.New("not found on Modrinth")
+
+// A ModrinthProject is a mod, modpack or other project on Modrinth.
+type ModrinthProject struct {
+	ID          string   `json:"id"`
+	Slug        string   `json:"slug"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	ProjectType string   `json:"project_type"`
+	Downloads   int      `json:"downloads"`
+	Loaders     []string `json:"loaders"`
+}
+
+// A ModrinthSearchHit is a project found by a Modrinth search.
+type ModrinthSearchHit struct {
+	ProjectID   string `json:"project_id"`
+	Slug        string `json:"slug"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	Downloads   int    `json:"downloads"`
+}
+
+// A ModrinthVersion is a single released version of a Modrinth project.
+type ModrinthVersion struct {
+	ID            string               `json:"id"`
+	ProjectID     string               `json:"project_id"`
+	Name          string               `json:"name"`
+	VersionNumber string               `json:"version_number"`
+	VersionType   string               `json:"version_type"`
+	GameVersions  []string             `json:"game_versions"`
+	Loaders       []string             `json:"loaders"`
+	DatePublished time.Time            `json:"date_published"`
+	Files         []ModrinthFile       `json:"files"`
+	Dependencies  []ModrinthDependency `json:"dependencies"`
+}
+
+// PrimaryFile returns the primary file of the version, or its first file if none is marked as primary.
+func (version ModrinthVersion) PrimaryFile() (ModrinthFile, bool) {
+	for _, file := range version.Files {
+		if file.Primary {
+			return file, true
+		}
+	}
+	if len(version.Files) > 0 {
+		return version.Files[0], true
+	}
+	return ModrinthFile{}, false
+}
+
+// A ModrinthFile is a file of a Modrinth version.
+type ModrinthFile struct {
+	Hashes struct {
+		Sha1   string `json:"sha1"`
+		Sha512 string `json:"sha512"`
+	} `json:"hashes"`
+	URL      string `json:"url"`
+	Filename string `json:"filename"`
+	Primary  bool   `json:"primary"`
+	Size     int64  `json:"size"`
+}
+
+// A ModrinthDependency is a project or version another version depends on.
+type ModrinthDependency struct {
+	VersionID      string `json:"version_id"`
+	ProjectID      string `json:"project_id"`
+	FileName       string `json:"file_name"`
+	DependencyType string `json:"dependency_type"` // "required", "optional", "incompatible" or "embedded"
+}
+
+// fetchModrinth retrieves path from the Modrinth API and decodes the response into v.
+func fetchModrinth(ctx context.Context, path string, query url.Values, v any) error {
+	u := ModrinthURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	resp, err := network.Get(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := network.CheckResponse(resp); err != nil {
+		var statusErr *network.HTTPStatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == 404 {
+			return ErrModrinthNotFound
+		}
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	return nil
+}
+
+// jsonList encodes values as a JSON array, as used by Modrinth query parameters.
+func jsonList(values ...string) string {
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+// SearchModrinth searches for mods compatible with any of the specified loaders and the game version.
+func SearchModrinth(ctx context.Context, query string, loaders []string, gameVersion string, limit int) ([]ModrinthSearchHit, error) {
+	facets := [][]string{{"project_type:mod"}, {"versions:" + gameVersion}}
+	var loaderFacet []string
+	for _, loader := range loaders {
+		loaderFacet = append(loaderFacet, "categories:"+loader)
+	}
+	facets = append(facets, loaderFacet)
+	data, _ := json.Marshal(facets)
+
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("facets", string(data))
+	params.Set("limit", strconv.Itoa(limit))
+
+	var result struct {
+		Hits []ModrinthSearchHit `json:"hits"`
+	}
+	if err := fetchModrinth(ctx, "/search", params, &result); err != nil {
+		return nil, err
+	}
+	return result.Hits, nil
+}
+
+// FetchModrinthProject retrieves a Modrinth project by its ID or slug.
+func FetchModrinthProject(ctx context.Context, idOrSlug string) (ModrinthProject, error) {
+	var project ModrinthProject
+	if err := fetchModrinth(ctx, "/project/"+url.PathEscape(idOrSlug), nil, &project); err != nil {
+		return ModrinthProject{}, fmt.Errorf("fetch project %q: %w", idOrSlug, err)
+	}
+	return project, nil
+}
+
+// FetchModrinthVersions retrieves all versions of a Modrinth project which support any of the specified loaders
+// and the game version, newest first.
+func FetchModrinthVersions(ctx context.Context, idOrSlug string, loaders []string, gameVersion string) ([]ModrinthVersion, error) {
+	params := url.Values{}
+	params.Set("loaders", jsonList(loaders...))
+	params.Set("game_versions", jsonList(gameVersion))
+
+	var versions []ModrinthVersion
+	if err := fetchModrinth(ctx, "/project/"+url.PathEscape(idOrSlug)+"/version", params, &versions); err != nil {
+		return nil, fmt.Errorf("fetch versions of project %q: %w", idOrSlug, err)
+	}
+	return versions, nil
+}
+
+// FetchModrinthVersion retrieves a single version of a Modrinth project by its ID.
+func FetchModrinthVersion(ctx context.Context, id string) (ModrinthVersion, error) {
+	var version ModrinthVersion
+	if err := fetchModrinth(ctx, "/version/"+url.PathEscape(id), nil, &version); err != nil {
+		return ModrinthVersion{}, fmt.Errorf("fetch version %q: %w", id, err)
+	}
+	return version, nil
+}
+
+// FetchModrinthVersionsByID retrieves several versions of Modrinth projects by their IDs.
+// Versions which don't exist are left out.
+func FetchModrinthVersionsByID(ctx context.Context, ids []string) ([]ModrinthVersion, error) {
+	params := url.Values{}
+	params.Set("ids", jsonList(ids...))
+
+	var versions []ModrinthVersion
+	if err := fetchModrinth(ctx, "/versions", params, &versions); err != nil {
+		return nil, fmt.Errorf("fetch versions: %w", err)
+	}
+	return versions, nil
+}
+
+// FetchModrinthProjects retrieves several Modrinth projects by their IDs or slugs.
+// Projects which don't exist are left out.
+func FetchModrinthProjects(ctx context.Context, ids []string) ([]ModrinthProject, error) {
+	params := url.Values{}
+	params.Set("ids", jsonList(ids...))
+
+	var projects []ModrinthProject
+	if err := fetchModrinth(ctx, "/projects", params, &projects); err != nil {
+		return nil, fmt.Errorf("fetch projects: %w", err)
+	}
+	return projects, nil
+}
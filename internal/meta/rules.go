@@ -0,0 +1,192 @@
+package meta
+
+import (
+	"encoding/json"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Features which rules of game arguments can depend on.
+const (
+	FeatureDemoUser              = "is_demo_user"
+	FeatureCustomResolution      = "has_custom_resolution"
+	FeatureQuickPlaysSupport     = "has_quick_plays_support"
+	FeatureQuickPlaySingleplayer = "is_quick_play_singleplayer"
+	FeatureQuickPlayMultiplayer  = "is_quick_play_multiplayer"
+	FeatureQuickPlayRealms       = "is_quick_play_realms"
+)
+
+// A Rule allows or disallows a library or argument on systems or with features it matches.
+type Rule struct {
+	Action   string          `json:"action"`
+	OS       ruleOS          `json:"os,omitempty"`
+	Features map[string]bool `json:"features,omitempty"`
+}
+
+type ruleOS struct {
+	Name    string `json:"name,omitempty"`    // "windows", "osx" or "linux"
+	Arch    string `json:"arch,omitempty"`    // For example "x86"
+	Version string `json:"version,omitempty"` // Regular expression matched against the OS version
+}
+
+// Rules is a list of rules, applied in order.
+type Rules []Rule
+
+// A RuleEnv is the system and enabled features rules are evaluated against.
+type RuleEnv struct {
+	OS       string // Name of the OS, in Mojang format
+	Arch     string // Architecture, in Mojang format
+	Version  string // Version of the OS
+	Features map[string]bool
+}
+
+// CurrentRuleEnv returns a RuleEnv for the running system, with the specified features enabled.
+func CurrentRuleEnv(features map[string]bool) RuleEnv {
+	return RuleEnv{
+		OS:       mojangOS(runtime.GOOS),
+		Arch:     mojangArch(runtime.GOARCH),
+		Version:  osVersion(),
+		Features: features,
+	}
+}
+
+// mojangOS converts a GOOS value to the OS name used in rules.
+func mojangOS(goos string) string {
+	if goos == "darwin" {
+		return "osx"
+	}
+	return goos
+}
+
+// mojangArch converts a GOARCH value to the architecture name used in rules.
+func mojangArch(goarch string) string {
+	switch goarch {
+	case "386":
+		return "x86"
+	case "amd64":
+		return "x86_64"
+	}
+	return goarch
+}
+
+// Matches reports whether rule applies in env.
+func (rule Rule) Matches(env RuleEnv) bool {
+	if rule.OS.Name != "" && rule.OS.Name != env.OS {
+		return false
+	}
+	if rule.OS.Arch != "" && rule.OS.Arch != env.Arch {
+		return false
+	}
+	if rule.OS.Version != "" {
+		re, err := regexp.Compile(rule.OS.Version)
+		if err != nil || !re.MatchString(env.Version) {
+			return false
+		}
+	}
+	for feature, value := range rule.Features {
+		if env.Features[feature] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Allows reports whether rules allow something in env.
+//
+// Without any rules, everything is allowed. Otherwise, everything is disallowed unless a rule matches,
+// and the last matching rule decides.
+func (rules Rules) Allows(env RuleEnv) bool {
+	if len(rules) == 0 {
+		return true
+	}
+	allowed := false
+	for _, rule := range rules {
+		if rule.Matches(env) {
+			allowed = rule.Action == "allow"
+		}
+	}
+	return allowed
+}
+
+// An Argument is a game or JVM argument with one or more values, which is only used if its rules allow it.
+type Argument struct {
+	Rules Rules
+	Value []string
+}
+
+func (arg *Argument) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err == nil {
+		*arg = Argument{Value: []string{value}}
+		return nil
+	}
+	var data struct {
+		Rules Rules           `json:"rules"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+	arg.Rules = data.Rules
+	arg.Value = nil
+	if err := json.Unmarshal(data.Value, &value); err == nil {
+		arg.Value = []string{value}
+		return nil
+	}
+	return json.Unmarshal(data.Value, &arg.Value)
+}
+
+// EvaluateArguments returns the values of all arguments which rules allow in env, in order.
+func EvaluateArguments(args []Argument, env RuleEnv) []string {
+	var values []string
+	for _, arg := range args {
+		if arg.Rules.Allows(env) {
+			values = append(values, arg.Value...)
+		}
+	}
+	return values
+}
+
+// legacyJvmArguments are the JVM arguments used for versions before 1.13, which do not specify any.
+var legacyJvmArguments = []Argument{
+	{Rules: Rules{{Action: "allow", OS: ruleOS{Name: "osx"}}}, Value: []string{"-XstartOnFirstThread"}},
+	{Rules: Rules{{Action: "allow", OS: ruleOS{Name: "windows"}}}, Value: []string{"-XX:HeapDumpPath=MojangTricksIntelDriversForPerformance_javaw.exe_minecraft.exe.heapdump"}},
+	{Rules: Rules{{Action: "allow", OS: ruleOS{Arch: "x86"}}}, Value: []string{"-Xss1M"}},
+	{Value: []string{"-Djava.library.path=${natives_directory}"}},
+	{Value: []string{"-Dminecraft.launcher.brand=${launcher_name}"}},
+	{Value: []string{"-Dminecraft.launcher.version=${launcher_version}"}},
+	{Value: []string{"-cp", "${classpath}"}},
+}
+
+// legacyGameArguments are game arguments added to minecraftArguments, for features that versions before 1.13 support.
+var legacyGameArguments = []Argument{
+	{Rules: Rules{{Action: "allow", Features: map[string]bool{FeatureDemoUser: true}}}, Value: []string{"--demo"}},
+	{Rules: Rules{{Action: "allow", Features: map[string]bool{FeatureCustomResolution: true}}}, Value: []string{"--width", "${resolution_width}", "--height", "${resolution_height}"}},
+}
+
+// GameArguments returns all game arguments of versionMeta.
+//
+// For versions which use minecraftArguments, it is split into single arguments.
+func (versionMeta VersionMeta) GameArguments() []Argument {
+	if versionMeta.MinecraftArguments == "" {
+		return versionMeta.Arguments.Game
+	}
+	var args []Argument
+	for _, value := range strings.Fields(versionMeta.MinecraftArguments) {
+		args = append(args, Argument{Value: []string{value}})
+	}
+	args = append(args, legacyGameArguments...)
+	return append(args, versionMeta.Arguments.Game...)
+}
+
+// JvmArguments returns all JVM arguments of versionMeta.
+//
+// For versions which use minecraftArguments, the default JVM arguments of the official launcher are included.
+func (versionMeta VersionMeta) JvmArguments() []Argument {
+	if versionMeta.MinecraftArguments == "" {
+		return versionMeta.Arguments.Jvm
+	}
+	return append(slices.Clone(legacyJvmArguments), versionMeta.Arguments.Jvm...)
+}
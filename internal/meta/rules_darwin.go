@@ -0,0 +1,9 @@
+package meta
+
+import "golang.org/x/sys/unix"
+
+// osVersion returns the version of macOS, such as "14.5".
+func osVersion() string {
+	version, _ := unix.Sysctl("kern.osproductversion")
+	return version
+}
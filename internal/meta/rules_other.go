@@ -0,0 +1,8 @@
+//go:build !unix && !windows
+
+package meta
+
+// osVersion returns an empty string, as the OS version is unknown on this system.
+func osVersion() string {
+	return ""
+}
@@ -0,0 +1,169 @@
+package meta_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/telecter/cmd-launcher/internal/meta"
+)
+
+// readVersionMeta reads version metadata from the testdata directory.
+//
+// The files there are excerpts of real version metadata, with checksums and sizes removed.
+func readVersionMeta(t *testing.T, id string) meta.VersionMeta {
+	data, err := os.ReadFile(filepath.Join("testdata", id+".json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading test data: %s", err)
+	}
+	var version meta.VersionMeta
+	if err := json.Unmarshal(data, &version); err != nil {
+		t.Fatalf("unexpected error parsing test data: %s", err)
+	}
+	return version
+}
+
+var (
+	linux   = meta.RuleEnv{OS: "linux", Arch: "x86_64", Version: "6.1.0"}
+	macOS   = meta.RuleEnv{OS: "osx", Arch: "arm64", Version: "14.5"}
+	win10   = meta.RuleEnv{OS: "windows", Arch: "x86_64", Version: "10.0"}
+	win7x86 = meta.RuleEnv{OS: "windows", Arch: "x86", Version: "6.1"}
+)
+
+func TestRules_Libraries(t *testing.T) {
+	tests := []struct {
+		version string
+		library string
+		env     meta.RuleEnv
+		want    bool
+	}{
+		{"1.12.2", "org.lwjgl.lwjgl:lwjgl:2.9.2-nightly-20140822", macOS, true},
+		{"1.12.2", "org.lwjgl.lwjgl:lwjgl:2.9.2-nightly-20140822", linux, false},
+		{"1.12.2", "org.lwjgl.lwjgl:lwjgl:2.9.4-nightly-20150209", linux, true},
+		{"1.12.2", "org.lwjgl.lwjgl:lwjgl:2.9.4-nightly-20150209", win10, true},
+		{"1.12.2", "org.lwjgl.lwjgl:lwjgl:2.9.4-nightly-20150209", macOS, false},
+		{"1.12.2", "tv.twitch:twitch-platform:6.5", win7x86, true},
+		{"1.12.2", "tv.twitch:twitch-platform:6.5", linux, false},
+		{"1.20.1", "org.lwjgl:lwjgl:3.3.1", linux, true},
+		{"1.20.1", "org.lwjgl:lwjgl:3.3.1:natives-linux", linux, true},
+		{"1.20.1", "org.lwjgl:lwjgl:3.3.1:natives-linux", macOS, false},
+		{"1.20.1", "org.lwjgl:lwjgl:3.3.1:natives-macos-arm64", macOS, true},
+		{"1.20.1", "org.lwjgl:lwjgl:3.3.1:natives-windows-x86", win7x86, true},
+		{"1.20.1", "org.lwjgl:lwjgl:3.3.1:natives-windows-x86", linux, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.library+"/"+tt.env.OS, func(t *testing.T) {
+			version := readVersionMeta(t, tt.version)
+			i := slices.IndexFunc(version.Libraries, func(library meta.Library) bool {
+				return library.Specifier.String() == tt.library
+			})
+			if i < 0 {
+				t.Fatalf("library %q not found in test data", tt.library)
+			}
+			if got := version.Libraries[i].Rules.Allows(tt.env); got != tt.want {
+				t.Errorf("wanted %t; got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEvaluateArguments_Jvm(t *testing.T) {
+	common := []string{
+		"-Djava.library.path=${natives_directory}",
+		"-Dminecraft.launcher.brand=${launcher_name}",
+		"-Dminecraft.launcher.version=${launcher_version}",
+		"-cp",
+		"${classpath}",
+	}
+	tests := []struct {
+		name string
+		env  meta.RuleEnv
+		want []string
+	}{
+		{"linux", linux, common},
+		{"macOS", macOS, append([]string{"-XstartOnFirstThread"}, common...)},
+		{"windows 10", win10, append([]string{
+			"-XX:HeapDumpPath=MojangTricksIntelDriversForPerformance_javaw.exe_minecraft.exe.heapdump",
+			"-Dos.name=Windows 10",
+			"-Dos.version=10.0",
+		}, common...)},
+		{"windows 7 x86", win7x86, append([]string{
+			"-XX:HeapDumpPath=MojangTricksIntelDriversForPerformance_javaw.exe_minecraft.exe.heapdump",
+			"-Xss1M",
+		}, common...)},
+	}
+	version := readVersionMeta(t, "1.20.1")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := meta.EvaluateArguments(version.Arguments.Jvm, tt.env)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("wanted %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEvaluateArguments_Game(t *testing.T) {
+	tests := []struct {
+		name     string
+		features map[string]bool
+		want     []string
+	}{
+		{"none", nil, nil},
+		{"demo", map[string]bool{meta.FeatureDemoUser: true}, []string{"--demo"}},
+		{"resolution", map[string]bool{meta.FeatureCustomResolution: true}, []string{
+			"--width", "${resolution_width}", "--height", "${resolution_height}",
+		}},
+		{"quick play", map[string]bool{meta.FeatureQuickPlayMultiplayer: true, meta.FeatureQuickPlaysSupport: false}, []string{
+			"--quickPlayMultiplayer", "${quickPlayMultiplayer}",
+		}},
+		{"disabled", map[string]bool{meta.FeatureDemoUser: false}, nil},
+	}
+	version := readVersionMeta(t, "1.20.1")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := linux
+			env.Features = tt.features
+			got := meta.EvaluateArguments(version.Arguments.Game, env)
+			// The first 22 arguments have no rules
+			if len(got) < 22 || got[0] != "--username" {
+				t.Fatalf("wanted arguments without rules to be included; got %q", got)
+			}
+			if !slices.Equal(got[22:], tt.want) {
+				t.Errorf("wanted %q after arguments without rules; got %q", tt.want, got[22:])
+			}
+		})
+	}
+}
+
+func TestRule_Matches(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		env  meta.RuleEnv
+		want bool
+	}{
+		{"empty", `{"action": "allow"}`, linux, true},
+		{"os", `{"action": "allow", "os": {"name": "linux"}}`, linux, true},
+		{"other os", `{"action": "allow", "os": {"name": "osx"}}`, linux, false},
+		{"arch", `{"action": "allow", "os": {"arch": "x86"}}`, win7x86, true},
+		{"other arch", `{"action": "allow", "os": {"arch": "x86"}}`, win10, false},
+		{"version", `{"action": "allow", "os": {"name": "windows", "version": "^10\\."}}`, win10, true},
+		{"other version", `{"action": "allow", "os": {"name": "windows", "version": "^10\\."}}`, win7x86, false},
+		{"feature", `{"action": "allow", "features": {"is_demo_user": true}}`, meta.RuleEnv{Features: map[string]bool{"is_demo_user": true}}, true},
+		{"missing feature", `{"action": "allow", "features": {"is_demo_user": true}}`, linux, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rule meta.Rule
+			if err := json.Unmarshal([]byte(tt.rule), &rule); err != nil {
+				t.Fatalf("unexpected error parsing rule: %s", err)
+			}
+			if got := rule.Matches(tt.env); got != tt.want {
+				t.Errorf("wanted %t; got %t", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build unix && !darwin
+
+package meta
+
+import "golang.org/x/sys/unix"
+
+// osVersion returns the release of the kernel, such as "6.1.0".
+func osVersion() string {
+	var uname unix.Utsname
+	if err := unix.Uname(&uname); err != nil {
+		return ""
+	}
+	return unix.ByteSliceToString(uname.Release[:])
+}
@@ -0,0 +1,13 @@
+package meta
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// osVersion returns the version of Windows, such as "10.0".
+func osVersion() string {
+	info := windows.RtlGetVersion()
+	return fmt.Sprintf("%d.%d", info.MajorVersion, info.MinorVersion)
+}
@@ -1,50 +1,75 @@
 package network
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"path/filepath"
+	"time"
 )
 
 var ErrNotCached = errors.New("data not cached and request failed")
 
 // A Cache stores and retrieves remote data to unmarshal either into JSON or a custom unmarshaler.
+//
+// Data with a RemoteSha1 is only fetched again if its checksum doesn't match. Other data is revalidated with the
+// server once it is older than MaxAge, or every time if AlwaysFetch is set, using the ETag and Last-Modified
+// headers of the previous response.
 type Cache[T any] struct {
 	Path        string
 	URL         string
 	RemoteSha1  string
 	AlwaysFetch bool
+	MaxAge      time.Duration                  // How long data is used without revalidation. 0 means forever, unless AlwaysFetch is set.
 	Unmarshaler func(data []byte, v any) error // Custom unmarshal function. Defaults to JSON.
 }
 
+// cacheInfo contains the validators of a cached response and when it was last fetched or revalidated.
+type cacheInfo struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Fetched      time.Time `json:"fetched"`
+}
+
 // Get checks the cache and checks if it is valid. If it is, its contents are returned. If not, they are fetched and then returned.
 func (cache Cache[T]) Get(v *T) error {
+	return cache.GetContext(context.Background(), v)
+}
+
+// GetContext is like Get, but any request is stopped when ctx is done.
+//
+// If ctx is offline, only cached data is used, even if it is stale.
+func (cache Cache[T]) GetContext(ctx context.Context, v *T) error {
 	download := true
 	if _, err := os.Stat(cache.Path); err == nil {
+		// Without a checksum, the cache is valid as long as it exists
+		download = false
 		if cache.RemoteSha1 != "" {
 			sum, err := cache.Sha1()
 			if err != nil {
 				return err
 			}
-			if cache.RemoteSha1 == "" || cache.RemoteSha1 == sum {
-				download = false
-			}
+			download = cache.RemoteSha1 != sum
 		}
 	}
 
-	if download || cache.AlwaysFetch {
+	if IsOffline(ctx) {
+		if download {
+			return fmt.Errorf("%w: %w", ErrNotCached, ErrOffline)
+		}
+	} else if download || cache.stale() {
 		if cache.URL == "" {
 			return fmt.Errorf("no URL to fetch from")
 		}
 
-		err := DownloadFile(DownloadEntry{
-			URL:  cache.URL,
-			Path: cache.Path,
-			Sha1: cache.RemoteSha1,
+		err := retry(ctx, func() error {
+			return cache.fetch(ctx, !download)
 		})
 		if err != nil && download {
 			return fmt.Errorf("%w: %w", ErrNotCached, err)
@@ -63,6 +88,108 @@ func (cache Cache[T]) Get(v *T) error {
 	}
 }
 
+// stale reports whether existing cached data should be revalidated.
+func (cache Cache[T]) stale() bool {
+	if cache.RemoteSha1 != "" {
+		return false
+	}
+	if cache.AlwaysFetch {
+		return true
+	}
+	if cache.MaxAge <= 0 {
+		return false
+	}
+	info, err := cache.info()
+	return err != nil || time.Since(info.Fetched) >= cache.MaxAge
+}
+
+// infoPath returns the path of the file which stores the cacheInfo.
+func (cache Cache[T]) infoPath() string {
+	return cache.Path + ".info"
+}
+
+// info reads the cacheInfo of the cache.
+func (cache Cache[T]) info() (cacheInfo, error) {
+	data, err := os.ReadFile(cache.infoPath())
+	if err != nil {
+		return cacheInfo{}, err
+	}
+	var info cacheInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return cacheInfo{}, err
+	}
+	return info, nil
+}
+
+// writeInfo writes info to the cacheInfo file of the cache.
+func (cache Cache[T]) writeInfo(info cacheInfo) error {
+	data, _ := json.Marshal(info)
+	return os.WriteFile(cache.infoPath(), data, 0644)
+}
+
+// fetch retrieves the data of the cache. If revalidate is set, the request is made conditional on the validators
+// of the cached data, and the data is only replaced if it has changed.
+func (cache Cache[T]) fetch(ctx context.Context, revalidate bool) error {
+	req, err := NewRequest(ctx, http.MethodGet, cache.URL, nil)
+	if err != nil {
+		return err
+	}
+	if revalidate {
+		if info, err := cache.info(); err == nil {
+			if info.ETag != "" {
+				req.Header.Set("If-None-Match", info.ETag)
+			}
+			if info.LastModified != "" {
+				req.Header.Set("If-Modified-Since", info.LastModified)
+			}
+		}
+	}
+
+	resp, err := Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	info := cacheInfo{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Fetched:      time.Now(),
+	}
+	if revalidate && resp.StatusCode == http.StatusNotModified {
+		if old, err := cache.info(); err == nil {
+			info.ETag, info.LastModified = old.ETag, old.LastModified
+		}
+		return cache.writeInfo(info)
+	}
+	if err := CheckResponse(resp); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if cache.RemoteSha1 != "" {
+		sum := sha1.Sum(data)
+		if hex.EncodeToString(sum[:]) != cache.RemoteSha1 {
+			return &ChecksumError{URL: cache.URL}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cache.Path), 0755); err != nil {
+		return fmt.Errorf("create directory for file %q: %w", cache.Path, err)
+	}
+	part := cache.Path + ".part"
+	if err := os.WriteFile(part, data, 0644); err != nil {
+		return fmt.Errorf("write file %q: %w", part, err)
+	}
+	if err := os.Rename(part, cache.Path); err != nil {
+		return fmt.Errorf("move file %q: %w", cache.Path, err)
+	}
+	return cache.writeInfo(info)
+}
+
 // Sha1 returns the SHA1 checksum of the cache
 func (cache Cache[T]) Sha1() (string, error) {
 	f, err := os.Open(cache.Path)
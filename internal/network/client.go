@@ -0,0 +1,176 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// A ClientConfig configures the HTTP client used for all network access.
+type ClientConfig struct {
+	Timeout               time.Duration     // Time limit for a whole request, including reading the body. 0 means no limit.
+	ConnectTimeout        time.Duration     // Time limit for establishing a connection. Defaults to 30 seconds.
+	ResponseHeaderTimeout time.Duration     // Time limit for waiting for response headers. Defaults to 60 seconds.
+	Proxy                 string            // Proxy URL. If empty, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used.
+	RootCAs               []string          // Paths to PEM files with extra root certificates to trust
+	UserAgent             string            // User-Agent header sent with every request
+	Headers               http.Header       // Extra headers sent with every request
+	Transport             http.RoundTripper // Custom transport. If set, ConnectTimeout, ResponseHeaderTimeout, Proxy and RootCAs are ignored.
+}
+
+var (
+	clientMu  sync.RWMutex
+	client    = &http.Client{Transport: newTransport(nil, 0, 0, nil)}
+	userAgent = "cmd-launcher"
+	headers   http.Header
+)
+
+// Configure replaces the HTTP client used for all network access with one built from config.
+func Configure(config ClientConfig) error {
+	transport := config.Transport
+	if transport == nil {
+		var proxy func(*http.Request) (*url.URL, error)
+		if config.Proxy != "" {
+			u, err := url.Parse(config.Proxy)
+			if err != nil {
+				return fmt.Errorf("parse proxy URL: %w", err)
+			}
+			proxy = http.ProxyURL(u)
+		}
+
+		var pool *x509.CertPool
+		if len(config.RootCAs) > 0 {
+			var err error
+			pool, err = x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			for _, path := range config.RootCAs {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return fmt.Errorf("read root certificates: %w", err)
+				}
+				if !pool.AppendCertsFromPEM(data) {
+					return fmt.Errorf("no certificates found in %q", path)
+				}
+			}
+		}
+		transport = newTransport(proxy, config.ConnectTimeout, config.ResponseHeaderTimeout, pool)
+	}
+
+	clientMu.Lock()
+	defer clientMu.Unlock()
+	client = &http.Client{
+		Transport: transport,
+		Timeout:   config.Timeout,
+	}
+	if config.UserAgent != "" {
+		userAgent = config.UserAgent
+	}
+	headers = config.Headers.Clone()
+	return nil
+}
+
+// SetClient replaces the HTTP client used for all network access.
+//
+// The User-Agent and extra headers set with Configure are still added to every request.
+func SetClient(c *http.Client) {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+	client = c
+}
+
+// Client returns the HTTP client used for all network access.
+func Client() *http.Client {
+	clientMu.RLock()
+	defer clientMu.RUnlock()
+	return client
+}
+
+// newTransport creates a transport based on http.DefaultTransport with the specified settings.
+//
+// If proxy is nil, the proxy is read from the environment. If pool is nil, the system root certificates are used.
+func newTransport(proxy func(*http.Request) (*url.URL, error), connectTimeout, headerTimeout time.Duration, pool *x509.CertPool) *http.Transport {
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+	if connectTimeout == 0 {
+		connectTimeout = 30 * time.Second
+	}
+	if headerTimeout == 0 {
+		headerTimeout = 60 * time.Second
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	transport.DialContext = (&net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.ResponseHeaderTimeout = headerTimeout
+	if pool != nil {
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return transport
+}
+
+// NewRequest creates a request with the configured User-Agent and extra headers.
+func NewRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	clientMu.RLock()
+	defer clientMu.RUnlock()
+	req.Header.Set("User-Agent", userAgent)
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+	return req, nil
+}
+
+// ErrOffline is returned for any request made with a context from WithOffline.
+var ErrOffline = errors.New("network access is disabled in offline mode")
+
+type offlineKey struct{}
+
+// WithOffline returns a copy of ctx in which all network access is disabled.
+//
+// Requests fail immediately with ErrOffline, and caches only use data that is already present.
+func WithOffline(ctx context.Context) context.Context {
+	return context.WithValue(ctx, offlineKey{}, true)
+}
+
+// IsOffline reports whether network access is disabled in ctx.
+func IsOffline(ctx context.Context) bool {
+	offline, _ := ctx.Value(offlineKey{}).(bool)
+	return offline
+}
+
+// Do sends req with the configured HTTP client.
+//
+// If a mirror is configured for the URL of req, its bases are tried first.
+// If the context of req is offline, no request is made and ErrOffline is returned.
+func Do(req *http.Request) (*http.Response, error) {
+	if IsOffline(req.Context()) {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, ErrOffline)
+	}
+	return doMirrored(Client(), req)
+}
+
+// Get sends a GET request to url with the configured HTTP client.
+func Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := NewRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return Do(req)
+}
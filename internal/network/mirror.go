@@ -0,0 +1,117 @@
+package network
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// A Mirror redirects requests for an upstream base URL to alternate base URLs.
+//
+// For example, with the upstream "https://libraries.minecraft.net" and the base "http://cache.lan/libraries",
+// "https://libraries.minecraft.net/a/b.jar" is requested from "http://cache.lan/libraries/a/b.jar".
+type Mirror struct {
+	Upstream  string   `toml:"upstream" json:"upstream"`
+	Bases     []string `toml:"bases" json:"bases"`         // Alternate base URLs, tried in order
+	Exclusive bool     `toml:"exclusive" json:"exclusive"` // Do not fall back to the upstream if all bases fail
+}
+
+var (
+	mirrorsMu sync.RWMutex
+	mirrors   []Mirror
+)
+
+// SetMirrors replaces the mirrors used for all network access.
+func SetMirrors(m []Mirror) {
+	mirrorsMu.Lock()
+	defer mirrorsMu.Unlock()
+	mirrors = m
+}
+
+// Mirrors returns the mirrors used for all network access.
+func Mirrors() []Mirror {
+	mirrorsMu.RLock()
+	defer mirrorsMu.RUnlock()
+	return mirrors
+}
+
+// Candidates returns all URLs to try for rawURL, in order.
+//
+// If no mirror matches rawURL, only rawURL itself is returned.
+func Candidates(rawURL string) []string {
+	for _, mirror := range Mirrors() {
+		upstream := strings.TrimSuffix(mirror.Upstream, "/")
+		rest, ok := strings.CutPrefix(rawURL, upstream)
+		if !ok || upstream == "" || (rest != "" && !strings.ContainsAny(rest[:1], "/?#")) {
+			continue
+		}
+		var urls []string
+		for _, base := range mirror.Bases {
+			urls = append(urls, strings.TrimSuffix(base, "/")+rest)
+		}
+		if !mirror.Exclusive || len(urls) == 0 {
+			urls = append(urls, rawURL)
+		}
+		return urls
+	}
+	return []string{rawURL}
+}
+
+// doMirrored sends req to each of its candidate URLs in turn, until one succeeds or all have been tried.
+//
+// The response or error of the last candidate is returned if all of them fail. If an error is returned,
+// the response is always nil.
+func doMirrored(c *http.Client, req *http.Request) (*http.Response, error) {
+	urls := Candidates(req.URL.String())
+	if len(urls) == 1 && urls[0] == req.URL.String() {
+		resp, err := c.Do(req)
+		if err != nil {
+			// A failed redirect returns a response with a closed body
+			return nil, err
+		}
+		return resp, nil
+	}
+
+	var resp *http.Response
+	var err error
+	for i, candidate := range urls {
+		r := req.Clone(req.Context())
+		r.URL, err = url.Parse(candidate)
+		if err != nil {
+			continue
+		}
+		r.Host = ""
+		if req.GetBody != nil {
+			r.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err = c.Do(r)
+		if i == len(urls)-1 || !shouldFallback(resp, err) || req.Context().Err() != nil {
+			break
+		}
+		if resp != nil {
+			resp.Body.Close()
+			resp = nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// shouldFallback reports whether a response from a mirror indicates that the next one should be tried.
+func shouldFallback(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusForbidden, http.StatusGone:
+		return true
+	}
+	return resp.StatusCode >= 500
+}
@@ -1,82 +1,353 @@
 package network
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"math"
+	"math/rand/v2"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
+	"syscall"
+	"time"
 )
 
 const MaxConcurrentDownloads = 6
 
 type DownloadEntry struct {
-	URL      string
-	Path     string
-	Sha1     string
-	FileMode os.FileMode
+	URL       string
+	Fallbacks []string // Alternate URLs of the same file, tried in order if downloading from URL fails
+	Path      string
+	Sha1      string
+	Size      int64 // Expected size in bytes, or 0 if unknown
+	FileMode  os.FileMode
+}
+
+// A ProgressWatcher receives events about the progress of downloads.
+//
+// It may be called from multiple goroutines at once.
+type ProgressWatcher func(event any)
+
+// DownloadStartedEvent is sent when the server has responded and a download begins.
+type DownloadStartedEvent struct {
+	Entry DownloadEntry
+	Size  int64 // Size of the whole file, from the entry or the response. 0 if unknown.
+}
+
+// DownloadProgressEvent is sent as data of a download is written.
+type DownloadProgressEvent struct {
+	Entry   DownloadEntry
+	Written int64 // Total bytes of the file written so far, including resumed data
+}
+
+// DownloadFinishedEvent is sent once a download has completed or failed for good.
+type DownloadFinishedEvent struct {
+	Entry DownloadEntry
+	Err   error
+}
+
+// A RetryPolicy controls how failed downloads are retried.
+//
+// Delays grow exponentially from BaseDelay up to MaxDelay, with full jitter applied.
+type RetryPolicy struct {
+	MaxAttempts int           // Total number of attempts, including the first one.
+	BaseDelay   time.Duration // Delay before the first retry.
+	MaxDelay    time.Duration // Upper bound for the delay between two attempts.
+}
+
+// Retry is the retry policy used for all downloads.
+var Retry = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// Delay returns a randomized delay to wait before the specified retry attempt, starting from 0.
+func (policy RetryPolicy) Delay(attempt int) time.Duration {
+	if policy.BaseDelay <= 0 {
+		return 0
+	}
+	// Delays which would overflow are clamped, so d is always positive
+	attempt = max(attempt, 0)
+	d := time.Duration(math.MaxInt64)
+	if attempt < 63 && policy.BaseDelay <= d>>attempt {
+		d = policy.BaseDelay << attempt
+	}
+	if policy.MaxDelay > 0 && d > policy.MaxDelay {
+		d = policy.MaxDelay
+	}
+	return rand.N(d) + 1
+}
+
+// ChecksumError is returned when a downloaded file does not match its expected checksum.
+type ChecksumError struct {
+	URL string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("invalid checksum from %q", e.URL)
+}
+
+// errPartialInvalid is returned when an incomplete download cannot be resumed and has to be restarted.
+var errPartialInvalid = errors.New("partial download is invalid")
+
+// IsTransient reports whether err is a temporary failure which may succeed if retried,
+// such as a server error, a reset connection or a timeout.
+func IsTransient(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests:
+			return true
+		}
+		return statusErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, errPartialInvalid) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE)
 }
 
 // DownloadFile downloads the specified DownloadEntry and saves it.
 //
-// All parent directories are created in order to create the file.
+// It is equivalent to DownloadFileContext with context.Background.
 func DownloadFile(entry DownloadEntry) error {
-	resp, err := http.Get(entry.URL)
-	if err != nil {
-		return err
+	return DownloadFileContext(context.Background(), entry)
+}
+
+// DownloadFileContext downloads the specified DownloadEntry and saves it, stopping when ctx is done.
+//
+// All parent directories are created in order to create the file. The data is first written to a
+// temporary ".part" file next to the destination, which is renamed once the download is complete and verified.
+// If an incomplete ".part" file is present, the download is resumed from where it stopped.
+//
+// Transient failures are retried according to Retry.
+func DownloadFileContext(ctx context.Context, entry DownloadEntry) error {
+	return downloadWithRetry(ctx, entry, nil)
+}
+
+// downloadWithRetry downloads entry according to Retry, sending progress events to watcher, if any.
+//
+// If the download fails, the fallback URLs of entry are tried in order.
+func downloadWithRetry(ctx context.Context, entry DownloadEntry, watcher ProgressWatcher) error {
+	err := retry(ctx, func() error {
+		return downloadFile(ctx, entry, watcher)
+	})
+	for _, url := range entry.Fallbacks {
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+		fallback := entry
+		fallback.URL = url
+		err = retry(ctx, func() error {
+			return downloadFile(ctx, fallback, watcher)
+		})
 	}
-	defer resp.Body.Close()
-	if err := CheckResponse(resp); err != nil {
-		return err
+	return err
+}
+
+// retry runs f until it succeeds, fails with an error that is not transient, or Retry.MaxAttempts is reached.
+func retry(ctx context.Context, f func() error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = f()
+		if err == nil || !IsTransient(err) || attempt+1 >= Retry.MaxAttempts {
+			break
+		}
+		if err := sleep(ctx, Retry.Delay(attempt)); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// sleep pauses for d, returning early with an error if ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
+}
+
+// downloadFile makes a single attempt to download entry, resuming any partial download.
+func downloadFile(ctx context.Context, entry DownloadEntry, watcher ProgressWatcher) error {
 	if err := os.MkdirAll(filepath.Dir(entry.Path), 0755); err != nil {
 		return fmt.Errorf("create directory for file %q: %w", entry.Path, err)
 	}
-	out, err := os.Create(entry.Path)
+	part := entry.Path + ".part"
+
+	out, err := os.OpenFile(part, os.O_CREATE|os.O_RDWR, 0644)
 	if err != nil {
-		return fmt.Errorf("create file %q: %w", entry.Path, err)
+		return fmt.Errorf("create file %q: %w", part, err)
 	}
 	defer out.Close()
 
-	if entry.FileMode != 0 {
-		if err := out.Chmod(entry.FileMode); err != nil {
-			return fmt.Errorf("set permissions for file %q: %w", entry.Path, err)
+	// Hash what has already been downloaded, so the checksum covers the whole file
+	hash := sha1.New()
+	offset, err := io.Copy(hash, out)
+	if err != nil {
+		return fmt.Errorf("read partial file %q: %w", part, err)
+	}
+
+	req, err := NewRequest(ctx, http.MethodGet, entry.URL, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+	resp, err := Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		out.Close()
+		os.Remove(part)
+		return errPartialInvalid
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		// Server honoured the range, append to the existing data
+	default:
+		if err := CheckResponse(resp); err != nil {
+			return err
 		}
+		if err := restart(out, &hash); err != nil {
+			return fmt.Errorf("truncate partial file %q: %w", part, err)
+		}
+		offset = 0
 	}
 
-	hash := sha1.New()
-	tee := io.TeeReader(resp.Body, hash)
+	var dst io.Writer = out
+	if watcher != nil {
+		size := entry.Size
+		if size == 0 && resp.ContentLength > 0 {
+			size = offset + resp.ContentLength
+		}
+		watcher(DownloadStartedEvent{Entry: entry, Size: size})
+		watcher(DownloadProgressEvent{Entry: entry, Written: offset})
+		dst = &progressWriter{w: out, entry: entry, written: offset, watcher: watcher}
+	}
 
-	if _, err := io.Copy(out, tee); err != nil {
+	if _, err := io.Copy(dst, io.TeeReader(resp.Body, hash)); err != nil {
 		return err
 	}
 
-	if entry.Sha1 != "" {
-		if hex.EncodeToString(hash.Sum(nil)) != entry.Sha1 {
-			return fmt.Errorf("invalid checksum from %q", entry.URL)
+	if entry.Sha1 != "" && hex.EncodeToString(hash.Sum(nil)) != entry.Sha1 {
+		out.Close()
+		os.Remove(part)
+		// A resumed download may have been corrupted by a changed remote file. Start over.
+		if offset > 0 {
+			return errPartialInvalid
 		}
+		return &ChecksumError{URL: entry.URL}
 	}
 
+	if entry.FileMode != 0 {
+		if err := out.Chmod(entry.FileMode); err != nil {
+			return fmt.Errorf("set permissions for file %q: %w", entry.Path, err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("write file %q: %w", part, err)
+	}
+	if err := os.Rename(part, entry.Path); err != nil {
+		return fmt.Errorf("move file %q: %w", entry.Path, err)
+	}
+	return nil
+}
+
+// A progressWriter sends a DownloadProgressEvent for every write to w.
+type progressWriter struct {
+	w       io.Writer
+	entry   DownloadEntry
+	written int64
+	watcher ProgressWatcher
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.written += int64(n)
+	pw.watcher(DownloadProgressEvent{Entry: pw.entry, Written: pw.written})
+	return n, err
+}
+
+// restart truncates f and resets h, so a download can be written from the beginning.
+func restart(f *os.File, h *hash.Hash) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	*h = sha1.New()
 	return nil
 }
 
 // StartDownloadEntries runs DownloadFile on each specified DownloadEntry and returns a channel with the download results.
+//
+// All results must be received from the channel. Use StartDownloadEntriesContext to be able to stop early.
 func StartDownloadEntries(entries []DownloadEntry) chan error {
+	return StartDownloadEntriesContext(context.Background(), entries, nil)
+}
+
+// StartDownloadEntriesContext runs DownloadFileContext on each specified DownloadEntry and returns a channel with the download results.
+//
+// Once ctx is done, no new downloads are started, running downloads are stopped and the channel is closed without
+// sending any more results. Callers which stop receiving from the channel early must cancel ctx.
+//
+// If watcher is not nil, it receives progress events for every download.
+//
+// Entries with the same Path as an earlier entry are skipped, as they would write to the same file at once.
+func StartDownloadEntriesContext(ctx context.Context, entries []DownloadEntry, watcher ProgressWatcher) chan error {
 	var wg sync.WaitGroup
 	results := make(chan error)
 	d := make(chan struct{}, MaxConcurrentDownloads)
+	seen := make(map[string]bool)
 	for _, entry := range entries {
+		if seen[entry.Path] {
+			continue
+		}
+		seen[entry.Path] = true
 		wg.Add(1)
 		go func(entry DownloadEntry) {
 			defer wg.Done()
 
-			d <- struct{}{}
-			err := DownloadFile(entry)
+			select {
+			case d <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			err := downloadWithRetry(ctx, entry, watcher)
 			<-d
-			results <- err
+			if watcher != nil {
+				watcher(DownloadFinishedEvent{Entry: entry, Err: err})
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case results <- err:
+			case <-ctx.Done():
+			}
 		}(entry)
 	}
 	go func() {
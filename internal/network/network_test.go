@@ -1,10 +1,20 @@
 package network_test
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"math"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/telecter/cmd-launcher/internal/meta"
 	"github.com/telecter/cmd-launcher/internal/network"
@@ -74,6 +84,101 @@ func TestDownloadFile(t *testing.T) {
 	}
 }
 
+// testFile returns test data and its SHA1 checksum.
+func testFile() ([]byte, string) {
+	data := bytes.Repeat([]byte("cmd-launcher"), 4096)
+	sum := sha1.Sum(data)
+	return data, hex.EncodeToString(sum[:])
+}
+
+func TestDownloadFile_Resume(t *testing.T) {
+	data, sum := testFile()
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(data))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path+".part", data[:1000], 0644); err != nil {
+		t.Fatalf("unexpected error writing partial file: %s", err)
+	}
+
+	err := network.DownloadFile(network.DownloadEntry{
+		URL:  server.URL,
+		Path: path,
+		Sha1: sum,
+	})
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes=1000-" {
+		t.Errorf("wanted one request for bytes=1000-; got: %q", ranges)
+	}
+	got, _ := os.ReadFile(path)
+	if !bytes.Equal(got, data) {
+		t.Error("downloaded file does not match remote file")
+	}
+	if _, err := os.Stat(path + ".part"); err == nil {
+		t.Error("partial file should not exist after download; but does")
+	}
+}
+
+func TestDownloadFile_Retry(t *testing.T) {
+	policy := network.Retry
+	t.Cleanup(func() { network.Retry = policy })
+	network.Retry = network.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	data, sum := testFile()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	err := network.DownloadFile(network.DownloadEntry{
+		URL:  server.URL,
+		Path: filepath.Join(t.TempDir(), "file"),
+		Sha1: sum,
+	})
+	if err != nil {
+		t.Errorf("wanted no error; got: %s", err)
+	}
+	if requests != 3 {
+		t.Errorf("wanted 3 requests; got %d", requests)
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  network.RetryPolicy
+		attempt int
+		max     time.Duration
+	}{
+		{"First", network.RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, 0, time.Second},
+		{"Capped", network.RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, 20, time.Minute},
+		{"Overflow", network.RetryPolicy{BaseDelay: 10 * time.Second, MaxDelay: time.Minute}, 30, time.Minute},
+		{"Unbounded", network.RetryPolicy{BaseDelay: 10 * time.Second}, 1000, math.MaxInt64},
+		{"Negative", network.RetryPolicy{BaseDelay: time.Second}, -1, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				if d := tt.policy.Delay(tt.attempt); d <= 0 || d > tt.max {
+					t.Fatalf("wanted delay in (0, %s]; got %s", tt.max, d)
+				}
+			}
+		})
+	}
+}
+
 func TestStartDownloadEntries(t *testing.T) {
 	results := network.StartDownloadEntries([]network.DownloadEntry{
 		{
@@ -88,6 +193,248 @@ func TestStartDownloadEntries(t *testing.T) {
 	}
 }
 
+func TestStartDownloadEntries_DuplicatePath(t *testing.T) {
+	data, sum := testFile()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	entry := network.DownloadEntry{
+		URL:  server.URL,
+		Path: filepath.Join(t.TempDir(), "file"),
+		Sha1: sum,
+	}
+	for err := range network.StartDownloadEntries([]network.DownloadEntry{entry, entry, entry}) {
+		if err != nil {
+			t.Errorf("wanted no error; got: %s", err)
+		}
+	}
+	if got, _ := os.ReadFile(entry.Path); !bytes.Equal(got, data) {
+		t.Error("downloaded file does not match remote file")
+	}
+}
+
+func TestStartDownloadEntriesContext_Cancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var entries []network.DownloadEntry
+	for i := range 10 {
+		entries = append(entries, network.DownloadEntry{
+			URL:  server.URL,
+			Path: filepath.Join(t.TempDir(), strconv.Itoa(i)),
+		})
+	}
+	results := network.StartDownloadEntriesContext(ctx, entries, nil)
+	cancel()
+
+	select {
+	case <-waitClosed(results):
+	case <-time.After(5 * time.Second):
+		t.Error("wanted results channel to be closed after cancel; but was not")
+	}
+}
+
+// waitClosed drains results and returns a channel which is closed once results is.
+func waitClosed(results chan error) chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for range results {
+		}
+		close(done)
+	}()
+	return done
+}
+
+func TestConfigure(t *testing.T) {
+	client := network.Client()
+	t.Cleanup(func() {
+		network.Configure(network.ClientConfig{})
+		network.SetClient(client)
+	})
+
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer server.Close()
+
+	err := network.Configure(network.ClientConfig{
+		Timeout:   time.Second,
+		UserAgent: "test-agent",
+		Headers:   http.Header{"X-Test": {"value"}},
+	})
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	resp, err := network.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	resp.Body.Close()
+	if header.Get("User-Agent") != "test-agent" || header.Get("X-Test") != "value" {
+		t.Errorf("wanted configured headers to be sent; got: %v", header)
+	}
+}
+
+// roundTripFunc is an http.RoundTripper implemented by a function.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestSetClient(t *testing.T) {
+	client := network.Client()
+	t.Cleanup(func() { network.SetClient(client) })
+
+	data, sum := testFile()
+	network.SetClient(&http.Client{
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			rec := httptest.NewRecorder()
+			rec.Write(data)
+			resp := rec.Result()
+			resp.Request = req
+			return resp, nil
+		}),
+	})
+
+	err := network.DownloadFile(network.DownloadEntry{
+		URL:  "https://example.invalid/file",
+		Path: filepath.Join(t.TempDir(), "file"),
+		Sha1: sum,
+	})
+	if err != nil {
+		t.Errorf("wanted no error; got: %s", err)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	t.Cleanup(func() { network.SetMirrors(nil) })
+	network.SetMirrors([]network.Mirror{
+		{Upstream: "https://libraries.minecraft.net/", Bases: []string{"http://a.lan/libs", "http://b.lan/"}},
+		{Upstream: "https://meta.fabricmc.net", Bases: []string{"http://a.lan/fabric"}, Exclusive: true},
+	})
+
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{
+			url:  "https://libraries.minecraft.net/a/b.jar",
+			want: []string{"http://a.lan/libs/a/b.jar", "http://b.lan/a/b.jar", "https://libraries.minecraft.net/a/b.jar"},
+		},
+		{
+			url:  "https://meta.fabricmc.net/v2/versions?x=1",
+			want: []string{"http://a.lan/fabric/v2/versions?x=1"},
+		},
+		{
+			url:  "https://meta.fabricmc.network/v2",
+			want: []string{"https://meta.fabricmc.network/v2"},
+		},
+		{
+			url:  "https://piston-meta.mojang.com/mc/game/version_manifest_v2.json",
+			want: []string{"https://piston-meta.mojang.com/mc/game/version_manifest_v2.json"},
+		},
+	}
+	for _, tt := range tests {
+		got := network.Candidates(tt.url)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("candidates for %q: got %q; wanted %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadFile_Fallbacks(t *testing.T) {
+	policy := network.Retry
+	t.Cleanup(func() { network.Retry = policy })
+	network.Retry = network.RetryPolicy{MaxAttempts: 1}
+
+	data, sum := testFile()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer up.Close()
+
+	path := filepath.Join(t.TempDir(), "file")
+	err := network.DownloadFile(network.DownloadEntry{
+		URL:       down.URL,
+		Fallbacks: []string{down.URL + "/other", up.URL},
+		Path:      path,
+		Sha1:      sum,
+	})
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, data) {
+		t.Error("wanted file to be downloaded from fallback")
+	}
+	if err := network.DownloadFile(network.DownloadEntry{URL: down.URL, Path: path + "2", Sha1: sum}); err == nil {
+		t.Error("wanted error without fallbacks; got nil")
+	}
+}
+
+func TestMirror_Fallback(t *testing.T) {
+	t.Cleanup(func() { network.SetMirrors(nil) })
+
+	data, sum := testFile()
+	broken := httptest.NewServer(http.NotFoundHandler())
+	defer broken.Close()
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/mirror/5f/file" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	defer working.Close()
+
+	network.SetMirrors([]network.Mirror{{
+		Upstream:  "https://resources.download.minecraft.net",
+		Bases:     []string{broken.URL, working.URL + "/mirror"},
+		Exclusive: true,
+	}})
+	err := network.DownloadFile(network.DownloadEntry{
+		URL:  "https://resources.download.minecraft.net/5f/file",
+		Path: filepath.Join(t.TempDir(), "file"),
+		Sha1: sum,
+	})
+	if err != nil {
+		t.Errorf("wanted no error; got: %s", err)
+	}
+}
+
+func TestMirror_InvalidLast(t *testing.T) {
+	t.Cleanup(func() { network.SetMirrors(nil) })
+
+	broken := httptest.NewServer(http.NotFoundHandler())
+	defer broken.Close()
+	network.SetMirrors([]network.Mirror{{
+		Upstream:  "https://resources.download.minecraft.net",
+		Bases:     []string{broken.URL, "http://%zz"},
+		Exclusive: true,
+	}})
+	req, err := network.NewRequest(context.Background(), http.MethodGet, "https://resources.download.minecraft.net/5f/file", nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating request: %s", err)
+	}
+	resp, err := network.Do(req)
+	if err == nil {
+		t.Fatal("wanted error; got nil")
+	}
+	if resp != nil {
+		t.Error("wanted no response with an error; got one")
+	}
+}
+
 func TestCheckResponse_OK(t *testing.T) {
 	err := network.CheckResponse(&http.Response{StatusCode: 200})
 	if err != nil {
@@ -143,3 +490,68 @@ func TestCache_Sha1(t *testing.T) {
 		t.Error("wanted checksum; got empty string")
 	}
 }
+
+func TestCache_Revalidate(t *testing.T) {
+	requests, notModified := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"id":"1.0-test"}`))
+	}))
+	defer server.Close()
+
+	cache := network.Cache[meta.VersionMeta]{
+		Path:        filepath.Join(t.TempDir(), "meta.json"),
+		URL:         server.URL,
+		AlwaysFetch: true,
+	}
+	for range 2 {
+		var data meta.VersionMeta
+		if err := cache.Get(&data); err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		if data.ID != "1.0-test" {
+			t.Errorf("wanted ID %q; got %q", "1.0-test", data.ID)
+		}
+	}
+	if requests != 2 || notModified != 1 {
+		t.Errorf("wanted 2 requests with 1 revalidated; got %d with %d revalidated", requests, notModified)
+	}
+}
+
+func TestCache_MaxAge(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"id":"1.0-test"}`))
+	}))
+	defer server.Close()
+
+	cache := network.Cache[meta.VersionMeta]{
+		Path:   filepath.Join(t.TempDir(), "meta.json"),
+		URL:    server.URL,
+		MaxAge: time.Hour,
+	}
+	var data meta.VersionMeta
+	for range 2 {
+		if err := cache.Get(&data); err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("wanted 1 request within max age; got %d", requests)
+	}
+
+	cache.MaxAge = time.Nanosecond
+	if err := cache.Get(&data); err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if requests != 2 {
+		t.Errorf("wanted stale data to be fetched again; got %d requests", requests)
+	}
+}
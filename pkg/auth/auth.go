@@ -53,15 +53,30 @@ type deviceCodeResponse struct {
 	Message         string `json:"message"`
 }
 
+// post sends a POST request with the specified content type and body, which is stopped once ctx is done.
+func post(ctx context.Context, url, contentType string, body string) (*http.Response, error) {
+	req, err := network.NewRequest(ctx, http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return network.Do(req)
+}
+
 // FetchDeviceCode returns a device code for the user to input to authenticate
 //
 // Used for the OAuth2 device code grant
 func FetchDeviceCode() (deviceCodeResponse, error) {
+	return FetchDeviceCodeContext(context.Background())
+}
+
+// FetchDeviceCodeContext is like FetchDeviceCode, but the request is stopped once ctx is done.
+func FetchDeviceCodeContext(ctx context.Context) (deviceCodeResponse, error) {
 	params := url.Values{
 		"client_id": {ClientID},
 		"scope":     {scope},
 	}
-	resp, err := http.Post("https://login.microsoftonline.com/consumers/oauth2/v2.0/devicecode", "application/x-www-form-urlencoded", strings.NewReader(params.Encode()))
+	resp, err := post(ctx, "https://login.microsoftonline.com/consumers/oauth2/v2.0/devicecode", "application/x-www-form-urlencoded", params.Encode())
 	if err != nil {
 		return deviceCodeResponse{}, err
 	}
@@ -91,9 +106,9 @@ type msaResponse struct {
 	ErrorDescription string `json:"error_description"`
 }
 
-func authenticateMSA(payload url.Values) (msaResponse, error) {
+func authenticateMSA(ctx context.Context, payload url.Values) (msaResponse, error) {
 	var data msaResponse
-	resp, err := http.Post("https://login.microsoftonline.com/consumers/oauth2/v2.0/token", "application/x-www-form-urlencoded", strings.NewReader(payload.Encode()))
+	resp, err := post(ctx, "https://login.microsoftonline.com/consumers/oauth2/v2.0/token", "application/x-www-form-urlencoded", payload.Encode())
 	if err != nil {
 		return msaResponse{}, err
 	}
@@ -116,7 +131,7 @@ type xblResponse struct {
 	NotAfter     time.Time `json:"NotAfter"`
 }
 
-func authenticateXBL(msaAccessToken string) (xblResponse, error) {
+func authenticateXBL(ctx context.Context, msaAccessToken string) (xblResponse, error) {
 	type properties struct {
 		AuthMethod string `json:"AuthMethod"`
 		SiteName   string `json:"SiteName"`
@@ -137,7 +152,7 @@ func authenticateXBL(msaAccessToken string) (xblResponse, error) {
 			TokenType:    "JWT",
 			RelyingParty: "http://auth.xboxlive.com",
 		})
-	resp, err := http.Post("https://user.auth.xboxlive.com/user/authenticate", "application/json", strings.NewReader(string(req)))
+	resp, err := post(ctx, "https://user.auth.xboxlive.com/user/authenticate", "application/json", string(req))
 	if err != nil {
 		return xblResponse{}, err
 	}
@@ -163,7 +178,7 @@ type xstsResponse struct {
 	XErr int `json:"XErr"`
 }
 
-func authenticateXSTS(xblToken string) (xstsResponse, error) {
+func authenticateXSTS(ctx context.Context, xblToken string) (xstsResponse, error) {
 	type properties struct {
 		SandboxID  string   `json:"SandboxId"`
 		UserTokens []string `json:"UserTokens"`
@@ -182,7 +197,7 @@ func authenticateXSTS(xblToken string) (xstsResponse, error) {
 		RelyingParty: "rp://api.minecraftservices.com/",
 		TokenType:    "JWT",
 	})
-	resp, err := http.Post("https://xsts.auth.xboxlive.com/xsts/authorize", "application/json", strings.NewReader(string(req)))
+	resp, err := post(ctx, "https://xsts.auth.xboxlive.com/xsts/authorize", "application/json", string(req))
 	if err != nil {
 		return xstsResponse{}, err
 	}
@@ -216,7 +231,7 @@ type minecraftProfile struct {
 	ErrorMessage string `json:"errorMessage"`
 }
 
-func authenticateMinecraft(xstsToken string, userhash string) (minecraftResponse, minecraftProfile, error) {
+func authenticateMinecraft(ctx context.Context, xstsToken string, userhash string) (minecraftResponse, minecraftProfile, error) {
 	type request struct {
 		IdentityToken string `json:"identityToken"`
 	}
@@ -224,7 +239,7 @@ func authenticateMinecraft(xstsToken string, userhash string) (minecraftResponse
 	reqBody, _ := json.Marshal(request{
 		IdentityToken: fmt.Sprintf("XBL3.0 x=%s;%s", userhash, xstsToken),
 	})
-	resp, err := http.Post("https://api.minecraftservices.com/authentication/login_with_xbox", "application/json", strings.NewReader(string(reqBody)))
+	resp, err := post(ctx, "https://api.minecraftservices.com/authentication/login_with_xbox", "application/json", string(reqBody))
 	if err != nil {
 		return minecraftResponse{}, minecraftProfile{}, err
 	}
@@ -237,9 +252,9 @@ func authenticateMinecraft(xstsToken string, userhash string) (minecraftResponse
 		return minecraftResponse{}, minecraftProfile{}, err
 	}
 
-	req, _ := http.NewRequest("GET", "https://api.minecraftservices.com/minecraft/profile", nil)
+	req, _ := network.NewRequest(ctx, "GET", "https://api.minecraftservices.com/minecraft/profile", nil)
 	req.Header.Add("Authorization", "Bearer "+data.AccessToken)
-	resp, err = http.DefaultClient.Do(req)
+	resp, err = network.Do(req)
 	if err != nil {
 		return minecraftResponse{}, minecraftProfile{}, err
 	}
@@ -261,26 +276,31 @@ var ErrNoAccount = errors.New("no account found")
 
 // Authenticate authenticates with all necessary endpoints, or cached data if available and returns a Session.
 func Authenticate() (Session, error) {
+	return AuthenticateContext(context.Background())
+}
+
+// AuthenticateContext is like Authenticate, but any requests are stopped once ctx is done.
+func AuthenticateContext(ctx context.Context) (Session, error) {
 	if Store.MSA.RefreshToken == "" {
 		return Session{}, ErrNoAccount
 	}
 	if !Store.MSA.isValid() {
-		if err := Store.MSA.refresh(); err != nil {
+		if err := Store.MSA.refresh(ctx); err != nil {
 			return Session{}, fmt.Errorf("authenticate with MSA: %w", err)
 		}
 	}
 	if !Store.XBL.isValid() {
-		if err := Store.XBL.refresh(); err != nil {
+		if err := Store.XBL.refresh(ctx); err != nil {
 			return Session{}, fmt.Errorf("authenticate with Xbox Live: %w", err)
 		}
 	}
 	if !Store.XSTS.isValid() {
-		if err := Store.XSTS.refresh(); err != nil {
+		if err := Store.XSTS.refresh(ctx); err != nil {
 			return Session{}, fmt.Errorf("authenticate with XSTS: %w", err)
 		}
 	}
 	if !Store.Minecraft.isValid() {
-		if err := Store.Minecraft.refresh(); err != nil {
+		if err := Store.Minecraft.refresh(ctx); err != nil {
 			return Session{}, fmt.Errorf("authenticate with Minecraft: %w", err)
 		}
 	}
@@ -301,6 +321,11 @@ func Authenticate() (Session, error) {
 //
 // This function blocks until a response has been received on the local authentication server.
 func AuthenticateWithRedirect(success, fail string) (Session, error) {
+	return AuthenticateWithRedirectContext(context.Background(), success, fail)
+}
+
+// AuthenticateWithRedirectContext is like AuthenticateWithRedirect, but stops waiting for a response once ctx is done.
+func AuthenticateWithRedirectContext(ctx context.Context, success, fail string) (Session, error) {
 	var code string
 	var err error
 
@@ -320,12 +345,19 @@ func AuthenticateWithRedirect(success, fail string) (Session, error) {
 		code = params.Get("code")
 		go server.Shutdown(context.Background())
 	})
+	stop := context.AfterFunc(ctx, func() {
+		server.Shutdown(context.Background())
+	})
+	defer stop()
 	server.ListenAndServe()
+	if ctx.Err() != nil {
+		return Session{}, ctx.Err()
+	}
 	if err != nil {
 		return Session{}, err
 	}
 
-	resp, err := authenticateMSA(url.Values{
+	resp, err := authenticateMSA(ctx, url.Values{
 		"client_id":    {ClientID},
 		"scope":        {scope},
 		"redirect_uri": {RedirectURI.String()},
@@ -337,15 +369,20 @@ func AuthenticateWithRedirect(success, fail string) (Session, error) {
 	}
 	Store.MSA.write(resp)
 
-	return Authenticate()
+	return AuthenticateContext(ctx)
 }
 
 // AuthenticateWithCode authenticates with a device code.
 //
 // This function blocks until the user has been authenticated, or another error has occurred.
 func AuthenticateWithCode(codeResp deviceCodeResponse) (Session, error) {
+	return AuthenticateWithCodeContext(context.Background(), codeResp)
+}
+
+// AuthenticateWithCodeContext is like AuthenticateWithCode, but stops polling once ctx is done.
+func AuthenticateWithCodeContext(ctx context.Context, codeResp deviceCodeResponse) (Session, error) {
 	for {
-		resp, err := authenticateMSA(url.Values{
+		resp, err := authenticateMSA(ctx, url.Values{
 			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
 			"client_id":   {ClientID},
 			"device_code": {codeResp.DeviceCode},
@@ -356,7 +393,11 @@ func AuthenticateWithCode(codeResp deviceCodeResponse) (Session, error) {
 
 		switch resp.Error {
 		case "authorization_pending":
-			time.Sleep(time.Second * time.Duration(codeResp.Interval))
+			select {
+			case <-ctx.Done():
+				return Session{}, ctx.Err()
+			case <-time.After(time.Second * time.Duration(codeResp.Interval)):
+			}
 			continue
 		case "authorization_declined":
 			return Session{}, fmt.Errorf("authorization was declined")
@@ -367,5 +408,5 @@ func AuthenticateWithCode(codeResp deviceCodeResponse) (Session, error) {
 		}
 		break
 	}
-	return Authenticate()
+	return AuthenticateContext(ctx)
 }
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -22,8 +23,8 @@ type msaAuthStore struct {
 func (store *msaAuthStore) isValid() bool {
 	return store.AccessToken != "" && store.Expires.After(time.Now())
 }
-func (store *msaAuthStore) refresh() error {
-	resp, err := authenticateMSA(url.Values{
+func (store *msaAuthStore) refresh(ctx context.Context) error {
+	resp, err := authenticateMSA(ctx, url.Values{
 		"client_id":     {ClientID},
 		"scope":         {scope},
 		"grant_type":    {"refresh_token"},
@@ -50,8 +51,8 @@ type xblAuthStore struct {
 func (store *xblAuthStore) isValid() bool {
 	return store.Token != "" && store.Userhash != "" && store.Expires.After(time.Now())
 }
-func (store *xblAuthStore) refresh() error {
-	resp, err := authenticateXBL(Store.MSA.AccessToken)
+func (store *xblAuthStore) refresh(ctx context.Context) error {
+	resp, err := authenticateXBL(ctx, Store.MSA.AccessToken)
 	if err != nil {
 		return err
 	}
@@ -72,8 +73,8 @@ type xstsAuthStore struct {
 func (store *xstsAuthStore) isValid() bool {
 	return store.Token != "" && store.Expires.After(time.Now())
 }
-func (store *xstsAuthStore) refresh() error {
-	resp, err := authenticateXSTS(Store.XBL.Token)
+func (store *xstsAuthStore) refresh(ctx context.Context) error {
+	resp, err := authenticateXSTS(ctx, Store.XBL.Token)
 	if err != nil {
 		return err
 	}
@@ -95,8 +96,8 @@ type minecraftAuthStore struct {
 func (store *minecraftAuthStore) isValid() bool {
 	return store.AccessToken != "" && store.Expires.After(time.Now())
 }
-func (store *minecraftAuthStore) refresh() error {
-	resp, profile, err := authenticateMinecraft(Store.XSTS.Token, Store.XBL.Userhash)
+func (store *minecraftAuthStore) refresh(ctx context.Context) error {
+	resp, profile, err := authenticateMinecraft(ctx, Store.XSTS.Token, Store.XBL.Userhash)
 	if err != nil {
 		return err
 	}
@@ -23,6 +23,8 @@ var JavaDir string // Mojang Java installations
 
 var AuthStorePath string // Path of the global authentication store
 
+var MirrorsPath string // Path of the mirror configuration
+
 // SetDirs sets all directories to defaults from rootDir. These values can also be changed individually.
 // However, they should not be changed between operations, as the launcher will not be able to find necessary files.
 func SetDirs(rootDir string) error {
@@ -34,6 +36,7 @@ func SetDirs(rootDir string) error {
 	TmpDir = filepath.Join(RootDir, "tmp")
 	JavaDir = filepath.Join(RootDir, "java")
 	AuthStorePath = filepath.Join(RootDir, "account.json")
+	MirrorsPath = filepath.Join(RootDir, "mirrors.toml")
 
 	if err := os.MkdirAll(rootDir, 0755); err != nil {
 		return fmt.Errorf("create root directory: %w", err)
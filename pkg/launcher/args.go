@@ -0,0 +1,161 @@
+package launcher
+
+import (
+	"crypto/md5"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/telecter/cmd-launcher/internal/meta"
+	env "github.com/telecter/cmd-launcher/pkg"
+)
+
+// LauncherName and LauncherVersion identify the launcher to the game.
+var (
+	LauncherName    = "cmd-launcher"
+	LauncherVersion = ""
+)
+
+// createArgs takes data from a launch environment, version metadata, and environment options to
+// create a set of game and Java arguments to pass when starting the game.
+//
+// Arguments from the metadata are included if their rules allow them, and all placeholders in them are replaced.
+// The classpath is not included, as it is added by Launch. gameAssets is the directory legacy versions expect
+// assets in, which is the assets directory for all other versions. If logConfig is not empty, the log4j
+// configuration at that path is used.
+func createArgs(launchEnv LaunchEnvironment, version meta.VersionMeta, options LaunchOptions, nativesDir, gameAssets, logConfig string) (java, game []string) {
+	gameOptions, _ := os.ReadFile(filepath.Join(launchEnv.GameDir, "options.txt"))
+	fullscreen := strings.Contains(string(gameOptions), "fullscreen:true")
+	resolution := options.WindowResolution
+
+	ruleEnv := meta.CurrentRuleEnv(map[string]bool{
+		meta.FeatureDemoUser:              options.Demo,
+		meta.FeatureCustomResolution:      !fullscreen && resolution.Width != 0 && resolution.Height != 0,
+		meta.FeatureQuickPlaySingleplayer: options.QuickPlayWorld != "",
+		meta.FeatureQuickPlayMultiplayer:  options.QuickPlayServer != "",
+	})
+	values := placeholders(launchEnv, version, options, nativesDir, gameAssets)
+
+	// Java arguments
+	if options.MinMemory != 0 {
+		java = append(java, fmt.Sprintf("-Xms%dm", options.MinMemory))
+	}
+	if options.MaxMemory != 0 {
+		java = append(java, fmt.Sprintf("-Xmx%dm", options.MaxMemory))
+	}
+	if options.JavaArgs != "" {
+		java = append(java, strings.Split(options.JavaArgs, " ")...)
+	}
+	jvm := meta.EvaluateArguments(version.JvmArguments(), ruleEnv)
+	for i := 0; i < len(jvm); i++ {
+		if (jvm[i] == "-cp" || jvm[i] == "-classpath") && i+1 < len(jvm) && jvm[i+1] == "${classpath}" {
+			i++
+			continue
+		}
+		java = append(java, expandPlaceholders(jvm[i], values))
+	}
+	if logConfig != "" {
+		arg := version.Logging.Client.Argument
+		if arg == "" {
+			arg = "-Dlog4j.configurationFile=${path}"
+		}
+		java = append(java, strings.ReplaceAll(arg, "${path}", logConfig))
+	}
+
+	// Game arguments
+	gameArgs := version.GameArguments()
+	for _, arg := range meta.EvaluateArguments(gameArgs, ruleEnv) {
+		game = append(game, expandPlaceholders(arg, values))
+	}
+	if !usesFeature(gameArgs, meta.FeatureQuickPlayMultiplayer) {
+		// Versions before 1.20 do not have quick play arguments
+		switch {
+		case options.QuickPlayServer != "":
+			game = append(game, "--quickPlayMultiplayer", options.QuickPlayServer)
+		case options.QuickPlayWorld != "":
+			game = append(game, "--quickPlaySingleplayer", options.QuickPlayWorld)
+		}
+	}
+	if options.DisableChat {
+		game = append(game, "--disableChat")
+	}
+	if options.DisableMultiplayer {
+		game = append(game, "--disableMultiplayer")
+	}
+	return java, game
+}
+
+// placeholders returns the values of all placeholders which can be used in arguments.
+func placeholders(launchEnv LaunchEnvironment, version meta.VersionMeta, options LaunchOptions, nativesDir, gameAssets string) map[string]string {
+	session := options.Session
+	id := session.UUID
+	if id == "" {
+		id = offlineUUID(session.Username)
+	}
+	separator := string(os.PathListSeparator)
+	return map[string]string{
+		"auth_player_name":      session.Username,
+		"auth_uuid":             id,
+		"auth_access_token":     session.AccessToken,
+		"auth_session":          fmt.Sprintf("token:%s:%s", session.AccessToken, id),
+		"auth_xuid":             "",
+		"clientid":              "",
+		"user_type":             "msa",
+		"user_properties":       "{}",
+		"version_name":          version.ID,
+		"version_type":          version.Type,
+		"game_directory":        launchEnv.GameDir,
+		"assets_root":           env.AssetsDir,
+		"game_assets":           gameAssets,
+		"assets_index_name":     version.AssetIndex.ID,
+		"natives_directory":     nativesDir,
+		"library_directory":     env.LibrariesDir,
+		"classpath":             strings.Join(launchEnv.Classpath, separator),
+		"classpath_separator":   separator,
+		"launcher_name":         LauncherName,
+		"launcher_version":      LauncherVersion,
+		"resolution_width":      strconv.Itoa(options.WindowResolution.Width),
+		"resolution_height":     strconv.Itoa(options.WindowResolution.Height),
+		"quickPlayPath":         "",
+		"quickPlaySingleplayer": options.QuickPlayWorld,
+		"quickPlayMultiplayer":  options.QuickPlayServer,
+		"quickPlayRealms":       "",
+	}
+}
+
+var placeholderPattern = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// expandPlaceholders replaces all placeholders in arg, such as "${version_name}", with their values.
+// Unknown placeholders are left unchanged.
+func expandPlaceholders(arg string, values map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(arg, func(placeholder string) string {
+		if value, ok := values[placeholder[2:len(placeholder)-1]]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// usesFeature reports whether any rule of args depends on feature.
+func usesFeature(args []meta.Argument, feature string) bool {
+	for _, arg := range args {
+		for _, rule := range arg.Rules {
+			if _, ok := rule.Features[feature]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// offlineUUID returns the UUID the game uses for a player without an account, which is derived from their username.
+func offlineUUID(username string) string {
+	sum := md5.Sum([]byte("OfflinePlayer:" + username))
+	sum[6] = sum[6]&0x0f | 0x30 // Version 3
+	sum[8] = sum[8]&0x3f | 0x80 // IETF variant
+	return uuid.UUID(sum).String()
+}
@@ -0,0 +1,131 @@
+package launcher
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A CrashError is returned by Launch when the game exits abnormally or writes a crash report.
+type CrashError struct {
+	ExitCode  int
+	Report    string // Path to the crash report or JVM crash log, if one was written
+	Exception string // Headline exception of the crash report, if any
+	Mod       string // Mod the crash report names as the suspected cause, if any
+
+	Err error // Error returned by the Runner, if any
+}
+
+func (e *CrashError) Error() string {
+	if e.Exception != "" {
+		return fmt.Sprintf("game crashed with exit code %d: %s", e.ExitCode, e.Exception)
+	}
+	return fmt.Sprintf("game crashed with exit code %d", e.ExitCode)
+}
+
+func (e *CrashError) Unwrap() error {
+	return e.Err
+}
+
+// detectCrash returns a CrashError if err is an abnormal exit of the game started at start in gameDir,
+// or if the game wrote a crash report since then. Otherwise, it returns err.
+func detectCrash(gameDir string, start time.Time, err error) error {
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return err
+	}
+
+	crash := &CrashError{Err: err}
+	if exitErr != nil {
+		crash.ExitCode = exitErr.ExitCode()
+	}
+	if report := newestFile(filepath.Join(gameDir, "crash-reports", "crash-*.txt"), start); report != "" {
+		crash.Report = report
+		crash.Exception, crash.Mod = parseCrashReport(report)
+	} else if log := newestFile(filepath.Join(gameDir, "hs_err_pid*.log"), start); log != "" {
+		crash.Report = log
+		crash.Exception = parseJVMCrashLog(log)
+	} else if err == nil {
+		return nil
+	}
+	return crash
+}
+
+// newestFile returns the most recently modified file matching pattern, if it was modified after since.
+func newestFile(pattern string, since time.Time) string {
+	matches, _ := filepath.Glob(pattern)
+	var newest string
+	var newestTime time.Time
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Before(since) {
+			continue
+		}
+		if info.ModTime().After(newestTime) {
+			newest, newestTime = path, info.ModTime()
+		}
+	}
+	return newest
+}
+
+// parseCrashReport returns the headline exception of the game crash report at path,
+// and the mod suspected of causing the crash, if Forge or Fabric added one to it.
+func parseCrashReport(path string) (exception, mod string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+
+	// The exception follows the description after an empty line
+	var afterDescription, suspectedNext bool
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "Description:"):
+			afterDescription = true
+		case afterDescription && exception == "" && line != "":
+			exception = line
+		case strings.HasPrefix(line, "Suspected Mod:"), strings.HasPrefix(line, "Suspected Mods:"):
+			_, value, _ := strings.Cut(line, ":")
+			value = strings.TrimSpace(value)
+			suspectedNext = value == ""
+			if value != "" && !strings.EqualFold(value, "none") && mod == "" {
+				mod = value
+			}
+		case suspectedNext && line != "":
+			suspectedNext = false
+			if mod == "" {
+				mod = line
+			}
+		}
+	}
+	return exception, mod
+}
+
+// parseJVMCrashLog returns the description of the error in the JVM crash log at path, such as "SIGSEGV (0xb) at pc=...".
+func parseJVMCrashLog(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var afterHeader bool
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "#"))
+		if strings.HasPrefix(line, "A fatal error has been detected") {
+			afterHeader = true
+		} else if afterHeader && line != "" {
+			return line
+		}
+	}
+	return ""
+}
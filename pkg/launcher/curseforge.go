@@ -0,0 +1,304 @@
+package launcher
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/telecter/cmd-launcher/internal/meta"
+	"github.com/telecter/cmd-launcher/internal/network"
+)
+
+// CurseForgeManifestName is the name of the manifest file in a CurseForge modpack.
+const CurseForgeManifestName = "manifest.json"
+
+// ErrNoThirdPartyDownload is returned when the author of a CurseForge project does not allow downloads outside of
+// the CurseForge app.
+var ErrNoThirdPartyDownload = errors.New("project does not allow third-party downloads")
+
+// A CurseForgeManifest is the manifest of a CurseForge modpack, which describes its game version, mod loader and files.
+type CurseForgeManifest struct {
+	Minecraft struct {
+		Version    string `json:"version"`
+		ModLoaders []struct {
+			ID      string `json:"id"` // Mod loader and version, such as "fabric-0.15.0"
+			Primary bool   `json:"primary"`
+		} `json:"modLoaders"`
+	} `json:"minecraft"`
+	ManifestType    string              `json:"manifestType"`
+	ManifestVersion int                 `json:"manifestVersion"`
+	Name            string              `json:"name"`
+	Version         string              `json:"version"`
+	Author          string              `json:"author"`
+	Files           []CurseForgeFileRef `json:"files"`
+	Overrides       string              `json:"overrides"` // Folder of the files copied into the instance
+}
+
+// A CurseForgeFileRef references a file of a CurseForge project which is part of a modpack.
+type CurseForgeFileRef struct {
+	ProjectID int  `json:"projectID"`
+	FileID    int  `json:"fileID"`
+	Required  bool `json:"required"` // Files which are not required have been disabled by the modpack author
+}
+
+// curseForgeLoaders maps the mod loader prefixes of CurseForge modpacks to loaders.
+var curseForgeLoaders = map[string]meta.Loader{
+	"fabric":   meta.LoaderFabric,
+	"quilt":    meta.LoaderQuilt,
+	"forge":    meta.LoaderForge,
+	"neoforge": meta.LoaderNeoForge,
+}
+
+// versions returns the game version, loader and loader version of the modpack.
+// If the modpack has more than one mod loader, the primary one is used.
+func (manifest CurseForgeManifest) versions() (gameVersion string, loader meta.Loader, loaderVersion string, err error) {
+	gameVersion = manifest.Minecraft.Version
+	if gameVersion == "" {
+		return "", "", "", fmt.Errorf("modpack does not specify a game version")
+	}
+	loaders := manifest.Minecraft.ModLoaders
+	if len(loaders) == 0 {
+		return gameVersion, meta.LoaderVanilla, "", nil
+	}
+	id := loaders[0].ID
+	for _, l := range loaders {
+		if l.Primary {
+			id = l.ID
+			break
+		}
+	}
+	name, loaderVersion, _ := strings.Cut(id, "-")
+	loader, ok := curseForgeLoaders[name]
+	if !ok {
+		return "", "", "", fmt.Errorf("unsupported modpack mod loader %q", id)
+	}
+	// Forge versions of modpacks don't include the game version
+	if loader == meta.LoaderForge && !strings.HasPrefix(loaderVersion, gameVersion+"-") {
+		loaderVersion = gameVersion + "-" + loaderVersion
+	}
+	return gameVersion, loader, loaderVersion, nil
+}
+
+// readCurseForgeManifest reads the manifest of the CurseForge modpack r.
+func readCurseForgeManifest(r *zip.Reader) (CurseForgeManifest, error) {
+	f, err := r.Open(CurseForgeManifestName)
+	if err != nil {
+		return CurseForgeManifest{}, fmt.Errorf("open modpack manifest: %w", err)
+	}
+	defer f.Close()
+	var manifest CurseForgeManifest
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
+		return CurseForgeManifest{}, fmt.Errorf("parse modpack manifest: %w", err)
+	}
+	if manifest.ManifestType != "minecraftModpack" {
+		return CurseForgeManifest{}, fmt.Errorf("unsupported modpack manifest type %q", manifest.ManifestType)
+	}
+	return manifest, nil
+}
+
+// A ResolvedFile is a file of a CurseForge modpack, available either for download or in a local directory.
+type ResolvedFile struct {
+	Name string // File name in the mods directory
+	URL  string // Download URL, if Path is empty
+	Path string // Path of a local copy of the file
+	Sha1 string // Checked for downloaded files, if not empty
+	Size int64
+}
+
+// A CurseForgeResolver finds the files of CurseForge projects referenced by a modpack.
+type CurseForgeResolver interface {
+	ResolveFile(ctx context.Context, projectID, fileID int) (ResolvedFile, error)
+}
+
+// CurseForgeAPIResolver resolves files with the CurseForge API, which requires an API key.
+type CurseForgeAPIResolver struct {
+	APIKey string
+}
+
+func (r CurseForgeAPIResolver) ResolveFile(ctx context.Context, projectID, fileID int) (ResolvedFile, error) {
+	if r.APIKey == "" {
+		return ResolvedFile{}, meta.ErrCurseForgeUnauthorized
+	}
+	file, err := meta.FetchCurseForgeFile(ctx, r.APIKey, projectID, fileID)
+	if err != nil {
+		return ResolvedFile{}, err
+	}
+	if file.DownloadURL == "" {
+		return ResolvedFile{}, ErrNoThirdPartyDownload
+	}
+	return ResolvedFile{
+		Name: file.FileName,
+		URL:  file.DownloadURL,
+		Sha1: file.Sha1(),
+		Size: file.FileLength,
+	}, nil
+}
+
+// CurseForgeDirResolver resolves files from a local directory, for use without network access or an API key.
+//
+// The file of a project is looked up as the only file in <Dir>/<project ID>/<file ID>/, keeping its original name.
+type CurseForgeDirResolver struct {
+	Dir string
+}
+
+func (r CurseForgeDirResolver) ResolveFile(ctx context.Context, projectID, fileID int) (ResolvedFile, error) {
+	dir := filepath.Join(r.Dir, strconv.Itoa(projectID), strconv.Itoa(fileID))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ResolvedFile{}, err
+	}
+	var files []os.DirEntry
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			files = append(files, entry)
+		}
+	}
+	if len(files) != 1 {
+		return ResolvedFile{}, fmt.Errorf("expected one file in %s; found %d", dir, len(files))
+	}
+	return ResolvedFile{
+		Name: files[0].Name(),
+		Path: filepath.Join(dir, files[0].Name()),
+	}, nil
+}
+
+// An UnresolvedFileError describes a file of a CurseForge modpack which could not be resolved.
+type UnresolvedFileError struct {
+	ProjectID int
+	FileID    int
+	Err       error
+}
+
+func (e *UnresolvedFileError) Error() string {
+	return fmt.Sprintf("project %d, file %d: %s", e.ProjectID, e.FileID, e.Err)
+}
+
+func (e *UnresolvedFileError) Unwrap() error {
+	return e.Err
+}
+
+// ImportCurseForge creates an instance from the CurseForge modpack (.zip) at path.
+//
+// The game version and mod loader of the instance are taken from the modpack manifest, so only the name and
+// configuration of options are used. If the name is empty, the name of the modpack is used. The required files of
+// the modpack are found with resolver and placed in the mods directory, and the overrides of the modpack are copied
+// into the instance directory.
+//
+// Files which can't be resolved or downloaded don't fail the import and are returned as UnresolvedFileErrors, so
+// they can be added by hand. If anything else fails, the instance is removed again.
+func ImportCurseForge(ctx context.Context, path string, options InstanceOptions, resolver CurseForgeResolver, watcher EventWatcher) (inst Instance, unresolved []error, err error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return Instance{}, nil, fmt.Errorf("open modpack: %w", err)
+	}
+	defer r.Close()
+	manifest, err := readCurseForgeManifest(&r.Reader)
+	if err != nil {
+		return Instance{}, nil, err
+	}
+
+	options.GameVersion, options.Loader, options.LoaderVersion, err = manifest.versions()
+	if err != nil {
+		return Instance{}, nil, err
+	}
+	if options.Name == "" {
+		options.Name = manifest.Name
+	}
+	if filepath.Base(options.Name) != options.Name {
+		return Instance{}, nil, fmt.Errorf("invalid instance name")
+	}
+	inst, err = CreateInstanceContext(ctx, options)
+	if err != nil {
+		return Instance{}, nil, fmt.Errorf("create instance: %w", err)
+	}
+	// Captured, as inst is zero when an error is returned
+	dir := inst.Dir()
+	defer func() {
+		if err != nil {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	if err := os.MkdirAll(inst.ModsDir(), 0755); err != nil {
+		return Instance{}, nil, fmt.Errorf("create mods directory: %w", err)
+	}
+	var entries []network.DownloadEntry
+	refs := make(map[string]CurseForgeFileRef) // Files to download by their destination
+	for _, ref := range manifest.Files {
+		if !ref.Required {
+			continue
+		}
+		file, err := resolver.ResolveFile(ctx, ref.ProjectID, ref.FileID)
+		if err != nil {
+			// Every other file would fail the same way
+			if ctx.Err() != nil || errors.Is(err, meta.ErrCurseForgeUnauthorized) || errors.Is(err, network.ErrOffline) {
+				return Instance{}, nil, fmt.Errorf("resolve modpack files: %w", err)
+			}
+			unresolved = append(unresolved, &UnresolvedFileError{ref.ProjectID, ref.FileID, err})
+			continue
+		}
+		if filepath.Base(file.Name) != file.Name {
+			return Instance{}, nil, fmt.Errorf("invalid file name %q", file.Name)
+		}
+		dest, err := joinWithin(inst.ModsDir(), file.Name)
+		if err != nil {
+			return Instance{}, nil, err
+		}
+		if file.Path != "" {
+			if err := copyTree(file.Path, dest); err != nil {
+				return Instance{}, nil, fmt.Errorf("copy %q: %w", file.Name, err)
+			}
+			continue
+		}
+		refs[dest] = ref
+		entries = append(entries, network.DownloadEntry{
+			URL:  file.URL,
+			Path: dest,
+			Sha1: file.Sha1,
+			Size: file.Size,
+		})
+	}
+	failed, err := downloadAll(ctx, entries, watcher)
+	if err != nil {
+		return Instance{}, nil, fmt.Errorf("download modpack files: %w", err)
+	}
+	for _, entry := range entries {
+		err, ok := failed[entry.Path]
+		if !ok {
+			continue
+		}
+		if errors.Is(err, network.ErrOffline) {
+			return Instance{}, nil, fmt.Errorf("download modpack files: %w", err)
+		}
+		// Incomplete data is of no use without the rest of the download
+		os.Remove(entry.Path + ".part")
+		ref := refs[entry.Path]
+		unresolved = append(unresolved, &UnresolvedFileError{ref.ProjectID, ref.FileID, err})
+	}
+
+	overrides := manifest.Overrides
+	if overrides == "" {
+		overrides = "overrides"
+	}
+	for _, f := range r.File {
+		name, ok := strings.CutPrefix(f.Name, overrides+"/")
+		// The instance configuration is never replaced
+		if !ok || name == "" || f.FileInfo().IsDir() || name == "instance.toml" || name == "instance.json" {
+			continue
+		}
+		dest, err := joinWithin(inst.Dir(), name)
+		if err != nil {
+			return Instance{}, nil, err
+		}
+		if err := extractZipFile(f, dest); err != nil {
+			return Instance{}, nil, fmt.Errorf("extract %q: %w", f.Name, err)
+		}
+	}
+	return inst, unresolved, nil
+}
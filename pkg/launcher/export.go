@@ -0,0 +1,167 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ExportOptions configures how a LaunchEnvironment is exported.
+//
+// By default, the access token of the session is replaced with "REDACTED", so the exported launch can
+// only be used to play offline.
+type ExportOptions struct {
+	// AccessToken is the access token of the session the launch environment was prepared with.
+	AccessToken string
+	// TokenEnv is the name of an environment variable the access token is read from when the exported launch is started.
+	TokenEnv string
+	// KeepToken includes the access token in the export as is.
+	KeepToken bool
+}
+
+// RedactedToken replaces the access token in exported launches, unless it is kept or read from an environment variable.
+const RedactedToken = "REDACTED"
+
+// launchSpec is the JSON format of an exported LaunchEnvironment.
+type launchSpec struct {
+	LaunchEnvironment
+	TokenEnv string `json:"token_env,omitempty"` // Environment variable which replaces "${<TokenEnv>}" in arguments
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (options ExportOptions) validate() error {
+	if options.TokenEnv != "" && !envNamePattern.MatchString(options.TokenEnv) {
+		return fmt.Errorf("invalid environment variable name %q", options.TokenEnv)
+	}
+	return nil
+}
+
+// replaceToken returns args with the access token replaced by replacement.
+func (options ExportOptions) replaceToken(args []string, replacement string) []string {
+	if options.AccessToken == "" || options.KeepToken {
+		return args
+	}
+	args = slices.Clone(args)
+	for i, arg := range args {
+		args[i] = strings.ReplaceAll(arg, options.AccessToken, replacement)
+	}
+	return args
+}
+
+// ExportLaunchJSON writes launchEnv to w as a JSON launch spec, which can be read with ImportLaunchJSON.
+func ExportLaunchJSON(w io.Writer, launchEnv LaunchEnvironment, options ExportOptions) error {
+	if err := options.validate(); err != nil {
+		return err
+	}
+	spec := launchSpec{LaunchEnvironment: launchEnv}
+	replacement := RedactedToken
+	// The variable is only needed if there is a token to replace
+	if options.TokenEnv != "" && options.AccessToken != "" && !options.KeepToken {
+		spec.TokenEnv = options.TokenEnv
+		replacement = "${" + options.TokenEnv + "}"
+	}
+	spec.JavaArgs = options.replaceToken(launchEnv.JavaArgs, replacement)
+	spec.GameArgs = options.replaceToken(launchEnv.GameArgs, replacement)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(spec)
+}
+
+// ImportLaunchJSON reads a JSON launch spec written by ExportLaunchJSON. The returned LaunchEnvironment can be passed to Launch.
+//
+// If the access token is read from an environment variable, it must be set.
+func ImportLaunchJSON(r io.Reader) (LaunchEnvironment, error) {
+	var spec launchSpec
+	if err := json.NewDecoder(r).Decode(&spec); err != nil {
+		return LaunchEnvironment{}, fmt.Errorf("parse launch spec: %w", err)
+	}
+	launchEnv := spec.LaunchEnvironment
+	if spec.TokenEnv != "" {
+		token, ok := os.LookupEnv(spec.TokenEnv)
+		if !ok {
+			return LaunchEnvironment{}, fmt.Errorf("environment variable %s with the access token is not set", spec.TokenEnv)
+		}
+		placeholder := "${" + spec.TokenEnv + "}"
+		for i, arg := range launchEnv.JavaArgs {
+			launchEnv.JavaArgs[i] = strings.ReplaceAll(arg, placeholder, token)
+		}
+		for i, arg := range launchEnv.GameArgs {
+			launchEnv.GameArgs[i] = strings.ReplaceAll(arg, placeholder, token)
+		}
+	}
+	return launchEnv, nil
+}
+
+// ExportLaunchScript writes launchEnv to w as a POSIX shell script which starts the game.
+//
+// The script starts the game like Launch does, but without running hooks or recording the session.
+func ExportLaunchScript(w io.Writer, launchEnv LaunchEnvironment, options ExportOptions) error {
+	if err := options.validate(); err != nil {
+		return err
+	}
+	// Names of environment variables are written to the script unquoted
+	for key := range launchEnv.Env {
+		if !envNamePattern.MatchString(key) {
+			return fmt.Errorf("invalid environment variable name %q", key)
+		}
+	}
+	for _, key := range launchEnv.UnsetEnv {
+		if !envNamePattern.MatchString(key) {
+			return fmt.Errorf("invalid environment variable name %q", key)
+		}
+	}
+	replacement := RedactedToken
+	if options.TokenEnv != "" {
+		// Replaced with a quoted expansion of the variable below
+		replacement = "\x00"
+	}
+	quote := func(args []string) string {
+		var quoted []string
+		for _, arg := range options.replaceToken(args, replacement) {
+			parts := strings.Split(arg, "\x00")
+			for i, part := range parts {
+				parts[i] = shellQuote(part)
+			}
+			quoted = append(quoted, strings.Join(parts, `"${`+options.TokenEnv+`}"`))
+		}
+		return strings.Join(quoted, " ")
+	}
+
+	fmt.Fprintln(w, "#!/bin/sh")
+	fmt.Fprintf(w, "# Starts instance %q (%s %s)\n", launchEnv.InstanceName, launchEnv.Loader, launchEnv.GameVersion)
+	fmt.Fprintln(w, "set -e")
+	if options.TokenEnv != "" && options.AccessToken != "" && !options.KeepToken {
+		fmt.Fprintf(w, ": \"${%s:?must be set to the access token}\"\n", options.TokenEnv)
+	}
+	fmt.Fprintf(w, "cd %s\n", shellQuote(launchEnv.GameDir))
+	for _, key := range launchEnv.UnsetEnv {
+		fmt.Fprintf(w, "unset %s\n", key)
+	}
+	keys := make([]string, 0, len(launchEnv.Env))
+	for key := range launchEnv.Env {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if !slices.Contains(launchEnv.UnsetEnv, key) {
+			fmt.Fprintf(w, "export %s=%s\n", key, shellQuote(launchEnv.Env[key]))
+		}
+	}
+
+	command := slices.Concat(launchEnv.Wrapper, []string{launchEnv.Java}, launchEnv.JavaArgs, []string{
+		"-cp", strings.Join(launchEnv.Classpath, string(os.PathListSeparator)), launchEnv.MainClass,
+	}, launchEnv.GameArgs)
+	_, err := fmt.Fprintf(w, "exec %s\n", quote(command))
+	return err
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,80 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/telecter/cmd-launcher/internal/meta"
+)
+
+// A HistoryEntry represents a single session of playing an instance.
+type HistoryEntry struct {
+	Start         time.Time   `json:"start"`
+	End           time.Time   `json:"end"`
+	ExitCode      int         `json:"exit_code"`
+	Username      string      `json:"username"`
+	GameVersion   string      `json:"game_version"`
+	Loader        meta.Loader `json:"mod_loader"`
+	LoaderVersion string      `json:"mod_loader_version,omitempty"`
+}
+
+// Duration returns how long the session lasted.
+func (entry HistoryEntry) Duration() time.Duration {
+	return entry.End.Sub(entry.Start)
+}
+
+// History returns all sessions of the instance recorded by Launch, oldest first.
+func (inst Instance) History() ([]HistoryEntry, error) {
+	return readHistory(inst.Dir())
+}
+
+// Playtime returns when the instance was last played and how long it has been played in total.
+//
+// If the instance has never been played, the time is zero.
+func (inst Instance) Playtime() (lastPlayed time.Time, total time.Duration, err error) {
+	history, err := inst.History()
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	for _, entry := range history {
+		total += entry.Duration()
+		if entry.Start.After(lastPlayed) {
+			lastPlayed = entry.Start
+		}
+	}
+	return lastPlayed, total, nil
+}
+
+func historyPath(gameDir string) string {
+	return filepath.Join(gameDir, "history.json")
+}
+
+// readHistory reads the history of the instance in gameDir.
+func readHistory(gameDir string) ([]HistoryEntry, error) {
+	data, err := os.ReadFile(historyPath(gameDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	var history []HistoryEntry
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("parse history: %w", err)
+	}
+	return history, nil
+}
+
+// recordHistory adds entry to the history of the instance in gameDir.
+func recordHistory(gameDir string, entry HistoryEntry) error {
+	history, err := readHistory(gameDir)
+	if err != nil {
+		return err
+	}
+	data, _ := json.MarshalIndent(append(history, entry), "", "  ")
+	return os.WriteFile(historyPath(gameDir), data, 0644)
+}
@@ -0,0 +1,83 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	env "github.com/telecter/cmd-launcher/pkg"
+)
+
+// Hooks are shell commands which are run at certain points of starting an instance.
+//
+// Hooks are run in the instance directory and receive environment variables describing the instance:
+// INST_NAME, INST_DIR, INST_MC_DIR, INST_MC_VERSION, INST_LOADER, INST_LOADER_VERSION and LAUNCHER_DIR.
+// Hooks run by Launch also receive INST_JAVA, and the post-exit hook receives INST_EXIT_CODE.
+type Hooks struct {
+	PrePrepare string `toml:"pre_prepare" json:"pre_prepare" comment:"Command to run before the instance is prepared"`
+	PreLaunch  string `toml:"pre_launch" json:"pre_launch"   comment:"Command to run before the game is started"`
+	PostExit   string `toml:"post_exit" json:"post_exit"     comment:"Command to run after the game has exited"`
+}
+
+// A HookError is returned when a hook command fails.
+type HookError struct {
+	Hook    string // Name of the hook, such as "pre_launch"
+	Command string
+	Err     error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("%s hook %q failed: %s", e.Hook, e.Command, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// runHook runs the command of a hook in dir with the specified environment variables.
+//
+// Nothing is run if command is empty.
+func runHook(ctx context.Context, hook, command, dir string, vars map[string]string) error {
+	if command == "" {
+		return nil
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	for k, v := range vars {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	if err := cmd.Run(); err != nil {
+		return &HookError{Hook: hook, Command: command, Err: err}
+	}
+	return nil
+}
+
+// hookVars returns the environment variables describing an instance passed to hooks.
+func hookVars(name, dir, gameVersion, loader, loaderVersion string) map[string]string {
+	return map[string]string{
+		"INST_NAME":           name,
+		"INST_DIR":            dir,
+		"INST_MC_DIR":         dir,
+		"INST_MC_VERSION":     gameVersion,
+		"INST_LOADER":         loader,
+		"INST_LOADER_VERSION": loaderVersion,
+		"LAUNCHER_DIR":        env.RootDir,
+	}
+}
+
+// launchHookVars returns the environment variables passed to hooks run by Launch.
+func launchHookVars(launchEnv LaunchEnvironment) map[string]string {
+	vars := hookVars(launchEnv.InstanceName, launchEnv.GameDir, launchEnv.GameVersion, string(launchEnv.Loader), launchEnv.LoaderVersion)
+	vars["INST_JAVA"] = launchEnv.Java
+	return vars
+}
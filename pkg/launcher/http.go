@@ -0,0 +1,42 @@
+package launcher
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/telecter/cmd-launcher/internal/meta"
+	"github.com/telecter/cmd-launcher/internal/network"
+)
+
+// HTTPConfig configures the HTTP client used for all network access of the launcher, including authentication.
+type HTTPConfig = network.ClientConfig
+
+// ConfigureHTTP replaces the HTTP client used for all network access with one built from config.
+func ConfigureHTTP(config HTTPConfig) error {
+	return network.Configure(config)
+}
+
+// A Mirror redirects requests for an upstream base URL, such as "https://libraries.minecraft.net", to alternate base URLs.
+type Mirror = network.Mirror
+
+// SetMirrors replaces the mirrors used for all network access.
+//
+// Bases of a mirror are tried in order, falling back to the next one if a request fails.
+func SetMirrors(mirrors ...Mirror) {
+	network.SetMirrors(mirrors)
+}
+
+// SetHTTPClient replaces the HTTP client used for all network access with client.
+//
+// The User-Agent and extra headers from ConfigureHTTP are still added to every request.
+func SetHTTPClient(client *http.Client) {
+	network.SetClient(client)
+}
+
+// SetMetadataMaxAge sets how long cached version lists, such as the version manifest and loader versions, are used
+// before they are revalidated with the server. The default is 10 minutes.
+//
+// A max age of 0 revalidates them on every use.
+func SetMetadataMaxAge(maxAge time.Duration) {
+	meta.ListMaxAge = maxAge
+}
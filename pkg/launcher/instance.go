@@ -1,6 +1,7 @@
 package launcher
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -54,13 +55,21 @@ type InstanceConfig struct {
 		Width  int `toml:"width" json:"width"`
 		Height int `toml:"height" json:"height"`
 	} `toml:"resolution" json:"resolution"                comment:"Game window resolution"`
-	Java      string `toml:"java" json:"java"             comment:"Path to a Java executable. If blank, a Mojang-provided JVM will be downloaded."`
-	JavaArgs  string `toml:"java_args" json:"java_args"   comment:"Extra arguments to pass to the JVM"`
-	CustomJar string `toml:"custom_jar" json:"custom_jar" comment:"Path to a custom JAR to use instead of the normal Minecraft client"`
-	MinMemory int    `toml:"min_memory" json:"min_memory" comment:"Minimum game memory, in MB"`
-	MaxMemory int    `toml:"max_memory" json:"max_memory" comment:"Maximum game memory, in MB"`
+	Java      string            `toml:"java" json:"java"             comment:"Path to a Java executable, or 'system' to use an installed Java. If blank, a Mojang-provided JVM will be downloaded."`
+	JavaArgs  string            `toml:"java_args" json:"java_args"   comment:"Extra arguments to pass to the JVM"`
+	CustomJar string            `toml:"custom_jar" json:"custom_jar" comment:"Path to a custom JAR to use instead of the normal Minecraft client"`
+	MinMemory int               `toml:"min_memory" json:"min_memory" comment:"Minimum game memory, in MB"`
+	MaxMemory int               `toml:"max_memory" json:"max_memory" comment:"Maximum game memory, in MB"`
+	LogConfig string            `toml:"log_config" json:"log_config" comment:"Path to a custom log4j configuration file. If blank, Mojang's configuration is used. Set to 'none' to disable it."`
+	Wrapper   []string          `toml:"wrapper" json:"wrapper"       comment:"Command to start the game with, for example ['gamemoderun'] or ['nice', '-n', '5']"`
+	Env       map[string]string `toml:"env" json:"env"               comment:"Environment variables to set for the game"`
+	UnsetEnv  []string          `toml:"unset_env" json:"unset_env"   comment:"Environment variables to remove for the game"`
+	Hooks     Hooks             `toml:"hooks" json:"hooks"           comment:"Shell commands to run before the instance is prepared, before the game is started and after it has exited"`
 }
 
+// LogConfigNone is the value of LogConfig which disables the log4j configuration.
+const LogConfigNone = "none"
+
 // InstanceOptions are options used to designate an instance's version and other parameters on creation.
 type InstanceOptions struct {
 	Name          string
@@ -72,7 +81,14 @@ type InstanceOptions struct {
 }
 
 // CreateInstance creates a new instance with the specified options.
+//
+// It is equivalent to CreateInstanceContext with context.Background.
 func CreateInstance(options InstanceOptions) (Instance, error) {
+	return CreateInstanceContext(context.Background(), options)
+}
+
+// CreateInstanceContext is like CreateInstance, but stops retrieving version metadata once ctx is done.
+func CreateInstanceContext(ctx context.Context, options InstanceOptions) (Instance, error) {
 	if options.Name == "" {
 		return Instance{}, fmt.Errorf("invalid instance name")
 	}
@@ -81,7 +97,7 @@ func CreateInstance(options InstanceOptions) (Instance, error) {
 		return Instance{}, fmt.Errorf("instance already exists")
 	}
 
-	version, err := meta.FetchAllVersionMeta(options.Loader, options.GameVersion, options.LoaderVersion)
+	version, err := meta.FetchAllVersionMeta(ctx, options.Loader, options.GameVersion, options.LoaderVersion)
 	if err != nil {
 		return Instance{}, err
 	}
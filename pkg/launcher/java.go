@@ -0,0 +1,184 @@
+package launcher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	env "github.com/telecter/cmd-launcher/pkg"
+)
+
+// JavaSystem is the value of Java in InstanceConfig which uses a compatible Java installation of the system
+// instead of a Mojang-provided runtime.
+const JavaSystem = "system"
+
+// ErrNoCompatibleJava is returned when no Java installation of the system has the required major version.
+var ErrNoCompatibleJava = errors.New("no compatible Java installation found")
+
+// A JavaInstallation is a Java runtime installed on the system.
+type JavaInstallation struct {
+	Path         string // Path to the Java executable
+	Version      string // Full version, for example "21.0.2" or "1.8.0_402"
+	MajorVersion int
+	Vendor       string
+	Arch         string // Architecture the runtime was built for, for example "amd64" or "aarch64"
+}
+
+// SystemJavaSelectedEvent is called when a Java installation of the system is used to start the game.
+type SystemJavaSelectedEvent struct {
+	Installation JavaInstallation
+}
+
+// JavaVersionMismatchEvent is called when the configured Java executable has a different major version than the game requires.
+type JavaVersionMismatchEvent struct {
+	Path     string
+	Required int
+	Found    int
+}
+
+// javaExecutable returns the path of the Java executable in the Java home directory dir.
+func javaExecutable(dir string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(dir, "bin", "java.exe")
+	}
+	return filepath.Join(dir, "bin", "java")
+}
+
+// ProbeJava runs the Java executable at path to find out its version, vendor and architecture.
+func ProbeJava(ctx context.Context, path string) (JavaInstallation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// Properties are printed to stderr
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, "-XshowSettings:properties", "-version")
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return JavaInstallation{}, fmt.Errorf("run Java: %w", err)
+	}
+
+	properties := make(map[string]string)
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), " = ")
+		if ok {
+			properties[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	major, err := parseJavaMajorVersion(properties["java.specification.version"])
+	if err != nil {
+		return JavaInstallation{}, fmt.Errorf("parse Java version: %w", err)
+	}
+	return JavaInstallation{
+		Path:         path,
+		Version:      properties["java.version"],
+		MajorVersion: major,
+		Vendor:       properties["java.vendor"],
+		Arch:         properties["os.arch"],
+	}, nil
+}
+
+// parseJavaMajorVersion returns the major version of a Java specification version, such as "1.8" or "21".
+func parseJavaMajorVersion(version string) (int, error) {
+	version = strings.TrimPrefix(version, "1.")
+	major, _, _ := strings.Cut(version, ".")
+	return strconv.Atoi(major)
+}
+
+// javaCandidates returns the paths of all Java executables which may be installed on the system.
+func javaCandidates() []string {
+	var candidates []string
+	if home := os.Getenv("JAVA_HOME"); home != "" {
+		candidates = append(candidates, javaExecutable(home))
+	}
+	name := filepath.Base(javaExecutable(""))
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir != "" {
+			candidates = append(candidates, filepath.Join(dir, name))
+		}
+	}
+
+	var homes []string
+	userHome, _ := os.UserHomeDir()
+	if userHome != "" {
+		homes = append(homes,
+			filepath.Join(userHome, ".sdkman", "candidates", "java", "*"),
+			filepath.Join(userHome, ".asdf", "installs", "java", "*"),
+		)
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		homes = append(homes, "/Library/Java/JavaVirtualMachines/*/Contents/Home")
+	case "windows":
+		for _, dir := range []string{os.Getenv("ProgramFiles"), os.Getenv("ProgramFiles(x86)")} {
+			if dir != "" {
+				homes = append(homes,
+					filepath.Join(dir, "Java", "*"),
+					filepath.Join(dir, "Eclipse Adoptium", "*"),
+					filepath.Join(dir, "Microsoft", "*"),
+				)
+			}
+		}
+	default:
+		homes = append(homes, "/usr/lib/jvm/*", "/usr/lib64/jvm/*")
+	}
+	for _, pattern := range homes {
+		matches, _ := filepath.Glob(pattern)
+		for _, home := range matches {
+			candidates = append(candidates, javaExecutable(home))
+		}
+	}
+	return candidates
+}
+
+// FindJavaInstallations returns all Java installations found on the system, in the order they were found.
+//
+// Installations are searched for in JAVA_HOME, PATH, the system's usual installation directories, and the
+// directories of SDKMAN and asdf. Runtimes downloaded by the launcher are not included.
+func FindJavaInstallations(ctx context.Context) ([]JavaInstallation, error) {
+	var installations []JavaInstallation
+	var seen []string
+	for _, path := range javaCandidates() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil || slices.Contains(seen, resolved) || isWithin(env.JavaDir, resolved) {
+			continue
+		}
+		seen = append(seen, resolved)
+
+		installation, err := ProbeJava(ctx, resolved)
+		if err != nil {
+			continue
+		}
+		installations = append(installations, installation)
+	}
+	return installations, nil
+}
+
+// FindCompatibleJava returns the first Java installation of the system with the specified major version.
+//
+// If there is none, ErrNoCompatibleJava is returned.
+func FindCompatibleJava(ctx context.Context, majorVersion int) (JavaInstallation, error) {
+	installations, err := FindJavaInstallations(ctx)
+	if err != nil {
+		return JavaInstallation{}, err
+	}
+	for _, installation := range installations {
+		if installation.MajorVersion == majorVersion {
+			return installation, nil
+		}
+	}
+	return JavaInstallation{}, fmt.Errorf("%w: Java %d is required", ErrNoCompatibleJava, majorVersion)
+}
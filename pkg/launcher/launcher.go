@@ -2,13 +2,17 @@
 package launcher
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/telecter/cmd-launcher/internal/meta"
 	"github.com/telecter/cmd-launcher/internal/network"
@@ -27,10 +31,33 @@ type LaunchOptions struct {
 	DisableMultiplayer bool
 	DisableChat        bool
 
+	// Offline disables all network access. Everything needed to start the game must already be present
+	// from an earlier preparation, otherwise a MissingFilesError is returned.
+	Offline bool
+
 	skipAssets    bool
 	skipLibraries bool
 }
 
+// ErrOffline is returned when data that is not present locally is needed in offline mode.
+var ErrOffline = network.ErrOffline
+
+// MissingFilesError is returned by Prepare in offline mode when files needed to start the game have not been downloaded.
+type MissingFilesError struct {
+	Paths []string
+}
+
+func (e *MissingFilesError) Error() string {
+	if len(e.Paths) == 1 {
+		return fmt.Sprintf("file %q is missing", e.Paths[0])
+	}
+	return fmt.Sprintf("%d files are missing, including %q", len(e.Paths), e.Paths[0])
+}
+
+func (e *MissingFilesError) Unwrap() error {
+	return ErrOffline
+}
+
 // An EventWatcher is a controller that can handle multiple types of events.
 type EventWatcher func(event any)
 
@@ -48,9 +75,29 @@ type AssetsResolvedEvent struct {
 }
 
 // DownloadingEvent is called when a download has progressed.
+//
+// BytesTotal is based on the sizes from the game metadata, so it may grow as downloads without a known size start.
 type DownloadingEvent struct {
-	Completed int
-	Total     int
+	Completed      int           // Number of files completed
+	Total          int           // Number of files to download
+	BytesCompleted int64         // Number of bytes downloaded
+	BytesTotal     int64         // Number of bytes to download
+	Throughput     float64       // Current download speed, in bytes per second
+	ETA            time.Duration // Estimated time remaining, or 0 if unknown
+}
+
+// DownloadStartedEvent is called when the download of a single file starts.
+type DownloadStartedEvent struct {
+	URL  string
+	Path string
+	Size int64 // Size in bytes, or 0 if unknown
+}
+
+// DownloadFinishedEvent is called when the download of a single file has completed.
+type DownloadFinishedEvent struct {
+	URL  string
+	Path string
+	Size int64
 }
 
 // PostProcessingEvent is called when, usually Forge, pre-processing begins.
@@ -69,41 +116,115 @@ func ConsoleRunner(cmd *exec.Cmd) error {
 
 // A LaunchEnvironment represents the information needed to start the game.
 type LaunchEnvironment struct {
-	GameDir   string
-	Java      string
-	MainClass string
-	Classpath []string
-	JavaArgs  []string
-	GameArgs  []string
+	GameDir   string   `json:"game_dir"`
+	Java      string   `json:"java"`
+	MainClass string   `json:"main_class"`
+	Classpath []string `json:"classpath"`
+	JavaArgs  []string `json:"java_args"`
+	GameArgs  []string `json:"game_args"`
+
+	Wrapper  []string          `json:"wrapper,omitempty"`   // Command to start Java with, if any
+	Env      map[string]string `json:"env,omitempty"`       // Environment variables to set
+	UnsetEnv []string          `json:"unset_env,omitempty"` // Environment variables to remove
+
+	// Used for the history and hooks of the instance by Launch
+	InstanceName  string      `json:"instance"`
+	Username      string      `json:"username"`
+	GameVersion   string      `json:"game_version"`
+	Loader        meta.Loader `json:"mod_loader"`
+	LoaderVersion string      `json:"mod_loader_version,omitempty"`
+	Hooks         Hooks       `json:"hooks"`
 }
 
 // Launch starts a LaunchEnvironment with the specified runner.
 //
 // The Java executable is checked and the classpath and command arguments are finalized.
+// If the game exits abnormally or writes a crash report, a *CrashError is returned.
+// Each session is recorded in the history of the instance in the game directory.
+//
+// The pre-launch hook is run before the game is started, and a *HookError is returned if it fails.
+// The post-exit hook is run after the game has exited.
 func Launch(launchEnv LaunchEnvironment, runner Runner) error {
 	if _, err := os.Stat(launchEnv.Java); err != nil {
 		return fmt.Errorf("Java executable does not exist") //lint:ignore ST1005 should be capitalized
 	}
+	vars := launchHookVars(launchEnv)
+	if err := runHook(context.Background(), "pre_launch", launchEnv.Hooks.PreLaunch, launchEnv.GameDir, vars); err != nil {
+		return err
+	}
 
 	javaArgs := append(launchEnv.JavaArgs, "-cp", strings.Join(launchEnv.Classpath, string(os.PathListSeparator)), launchEnv.MainClass)
-	cmd := exec.Command(launchEnv.Java, append(javaArgs, launchEnv.GameArgs...)...)
-	cmd.Dir = launchEnv.GameDir
-	return runner(cmd)
+	cmd := launchEnv.javaCommand(context.Background(), append(javaArgs, launchEnv.GameArgs...)...)
+
+	start := time.Now()
+	// File modification times may be less precise than the clock
+	err := detectCrash(launchEnv.GameDir, start.Truncate(time.Second), runner(cmd))
+
+	var crash *CrashError
+	if err != nil && !errors.As(err, &crash) {
+		// The game did not run
+		return err
+	}
+	entry := HistoryEntry{
+		Start:         start,
+		End:           time.Now(),
+		Username:      launchEnv.Username,
+		GameVersion:   launchEnv.GameVersion,
+		Loader:        launchEnv.Loader,
+		LoaderVersion: launchEnv.LoaderVersion,
+	}
+	if crash != nil {
+		entry.ExitCode = crash.ExitCode
+	}
+	if historyErr := recordHistory(launchEnv.GameDir, entry); historyErr != nil && err == nil {
+		err = fmt.Errorf("record history: %w", historyErr)
+	}
+
+	vars["INST_EXIT_CODE"] = strconv.Itoa(entry.ExitCode)
+	if hookErr := runHook(context.Background(), "post_exit", launchEnv.Hooks.PostExit, launchEnv.GameDir, vars); hookErr != nil && err == nil {
+		err = hookErr
+	}
+	return err
 }
 
 // Prepare prepares the instance to be launched, returning a LaunchEnvironment, with the provided options and sends events to watcher.
+//
+// It is equivalent to PrepareContext with context.Background.
 func Prepare(inst *Instance, options LaunchOptions, watcher EventWatcher) (LaunchEnvironment, error) {
+	return PrepareContext(context.Background(), inst, options, watcher)
+}
+
+// PrepareContext is like Prepare, but stops all metadata requests, downloads and post processors once ctx is done.
+func PrepareContext(ctx context.Context, inst *Instance, options LaunchOptions, watcher EventWatcher) (LaunchEnvironment, error) {
 	var downloads []network.DownloadEntry
 
-	version, err := meta.FetchAllVersionMeta(inst.Loader, inst.GameVersion, inst.LoaderVersion)
+	if options.Offline {
+		ctx = network.WithOffline(ctx)
+	}
+
+	vars := hookVars(inst.Name, inst.Dir(), inst.GameVersion, string(inst.Loader), inst.LoaderVersion)
+	if err := runHook(ctx, "pre_prepare", options.Hooks.PrePrepare, inst.Dir(), vars); err != nil {
+		return LaunchEnvironment{}, err
+	}
+
+	version, err := meta.FetchAllVersionMeta(ctx, inst.Loader, inst.GameVersion, inst.LoaderVersion)
 	if err != nil {
 		return LaunchEnvironment{}, fmt.Errorf("retrieve metadata: %w", err)
 	}
 
 	launchEnv := LaunchEnvironment{
-		GameDir:   inst.Dir(),
-		Java:      options.Java,
-		MainClass: version.MainClass,
+		GameDir:       inst.Dir(),
+		Java:          options.Java,
+		MainClass:     version.MainClass,
+		InstanceName:  inst.Name,
+		Username:      options.Session.Username,
+		GameVersion:   inst.GameVersion,
+		Loader:        inst.Loader,
+		LoaderVersion: inst.LoaderVersion,
+		Hooks:         options.Hooks,
+		Wrapper:       options.Wrapper,
+		Env:           options.Env,
+		UnsetEnv:      options.UnsetEnv,
 	}
 	watcher(MetadataResolvedEvent{})
 
@@ -112,7 +233,7 @@ func Prepare(inst *Instance, options LaunchOptions, watcher EventWatcher) (Launc
 		version.Libraries = append(version.Libraries, version.Client())
 	}
 
-	installedLibs, requiredLibs := filterLibraries(version.Libraries)
+	installedLibs, requiredLibs := filterLibraries(ctx, version.Libraries)
 	if !options.skipLibraries {
 		for _, lib := range requiredLibs {
 			if lib.ShouldInstall {
@@ -130,7 +251,7 @@ func Prepare(inst *Instance, options LaunchOptions, watcher EventWatcher) (Launc
 	})
 
 	// Download asset index and add all necessary asset download entries
-	assetIndex, err := meta.DownloadAssetIndex(version)
+	assetIndex, err := meta.DownloadAssetIndex(ctx, version)
 	if err != nil {
 		return LaunchEnvironment{}, fmt.Errorf("retrieve asset index: %w", err)
 	}
@@ -140,27 +261,90 @@ func Prepare(inst *Instance, options LaunchOptions, watcher EventWatcher) (Launc
 	watcher(AssetsResolvedEvent{Total: len(assetIndex.Objects)})
 
 	// If no Java path is present, fetch Mojang Java downloads
+	requiredJava := version.JavaVersion.MajorVersion
+	if requiredJava == 0 {
+		// Versions without a Java version in their metadata predate Java 16
+		requiredJava = 8
+	}
 	var symlinks map[string]string
-	if launchEnv.Java == "" {
-		manifest, err := meta.FetchJavaManifest(version.JavaVersion.Component)
+	switch launchEnv.Java {
+	case "":
+		manifest, err := meta.FetchJavaManifest(ctx, version.JavaVersion.Component)
+		if errors.Is(err, meta.ErrJavaBadSystem) || errors.Is(err, meta.ErrJavaNoVersion) {
+			// Mojang does not provide a runtime for this system, but one may be installed
+			installation, findErr := FindCompatibleJava(ctx, requiredJava)
+			if findErr != nil {
+				return LaunchEnvironment{}, fmt.Errorf("fetch Java manifest: %w", err)
+			}
+			launchEnv.Java = installation.Path
+			watcher(SystemJavaSelectedEvent{Installation: installation})
+			break
+		}
 		if err != nil {
 			return LaunchEnvironment{}, fmt.Errorf("fetch Java manifest: %w", err)
 		}
 		var entries []network.DownloadEntry
 		entries, symlinks = manifest.DownloadEntries(version.JavaVersion.Component)
 		downloads = append(downloads, entries...)
+		launchEnv.Java = javaExecutable(filepath.Join(env.JavaDir, version.JavaVersion.Component))
+	case JavaSystem:
+		installation, err := FindCompatibleJava(ctx, requiredJava)
+		if err != nil {
+			return LaunchEnvironment{}, fmt.Errorf("find system Java: %w", err)
+		}
+		launchEnv.Java = installation.Path
+		watcher(SystemJavaSelectedEvent{Installation: installation})
+	default:
+		installation, err := ProbeJava(ctx, launchEnv.Java)
+		if err == nil && installation.MajorVersion != requiredJava {
+			watcher(JavaVersionMismatchEvent{Path: launchEnv.Java, Required: requiredJava, Found: installation.MajorVersion})
+		}
+	}
 
-		java := "java"
-		if runtime.GOOS == "windows" {
-			java = "java.exe"
+	// Use Mojang's log4j configuration, unless disabled or replaced by the instance
+	var logConfig string
+	switch options.LogConfig {
+	case "":
+		if version.Logging.Client.File.URL != "" {
+			logConfig = meta.LoggingConfigPath(version.Logging.Client.File.ID)
+			downloads = append(downloads, version.LoggingDownloadEntries()...)
+		}
+	case LogConfigNone:
+	default:
+		logConfig = options.LogConfig
+		if !filepath.IsAbs(logConfig) {
+			logConfig = filepath.Join(inst.Dir(), logConfig)
+		}
+		if _, err := os.Stat(logConfig); err != nil {
+			return LaunchEnvironment{}, fmt.Errorf("find log configuration: %w", err)
 		}
-		launchEnv.Java = filepath.Join(env.JavaDir, version.JavaVersion.Component, "bin", java)
 	}
 
-	if err := download(downloads, symlinks, watcher); err != nil {
+	if options.Offline && len(downloads) > 0 {
+		err := &MissingFilesError{}
+		for _, entry := range downloads {
+			err.Paths = append(err.Paths, entry.Path)
+		}
+		return LaunchEnvironment{}, err
+	}
+	if err := download(ctx, downloads, symlinks, watcher); err != nil {
 		return LaunchEnvironment{}, fmt.Errorf("download files: %w", err)
 	}
 
+	// Versions before 1.7.3 expect assets by their names
+	gameAssets := env.AssetsDir
+	switch {
+	case assetIndex.MapToResources:
+		gameAssets = filepath.Join(inst.Dir(), "resources")
+	case assetIndex.Virtual:
+		gameAssets = filepath.Join(env.AssetsDir, "virtual", version.AssetIndex.ID)
+	}
+	if gameAssets != env.AssetsDir && !options.skipAssets {
+		if err := assetIndex.Reconstruct(gameAssets); err != nil {
+			return LaunchEnvironment{}, fmt.Errorf("reconstruct legacy assets: %w", err)
+		}
+	}
+
 	// Extract LWJGL 2 natives for legacy versions (pre-1.13).
 	// This is a no-op for modern versions that use LWJGL 3.
 	allLibs := append(installedLibs, requiredLibs...)
@@ -173,12 +357,12 @@ func Prepare(inst *Instance, options LaunchOptions, watcher EventWatcher) (Launc
 	var processors []meta.ForgeProcessor
 	switch inst.Loader {
 	case meta.LoaderForge:
-		processors, err = meta.Forge.FetchPostProcessors(version.ID, version.LoaderID)
+		processors, err = meta.Forge.FetchPostProcessors(ctx, version.ID, version.LoaderID)
 		if err != nil {
 			return LaunchEnvironment{}, fmt.Errorf("fetch Forge post processors: %w", err)
 		}
 	case meta.LoaderNeoForge:
-		processors, err = meta.Neoforge.FetchPostProcessors(version.ID, version.LoaderID)
+		processors, err = meta.Neoforge.FetchPostProcessors(ctx, version.ID, version.LoaderID)
 		if err != nil {
 			return LaunchEnvironment{}, fmt.Errorf("fetch NeoForge post processors: %w", err)
 		}
@@ -187,13 +371,11 @@ func Prepare(inst *Instance, options LaunchOptions, watcher EventWatcher) (Launc
 	if len(processors) > 0 {
 		watcher(PostProcessingEvent{})
 		// Run any available processors
-		if err := postProcess(launchEnv, processors); err != nil {
+		if err := postProcess(ctx, launchEnv, processors); err != nil {
 			return LaunchEnvironment{}, fmt.Errorf("run post processors: %w", err)
 		}
 	}
 
-	launchEnv.JavaArgs, launchEnv.GameArgs = createArgs(launchEnv, version, options, inst.NativesDir())
-
 	// Finalize classpath
 	for _, library := range allLibs {
 		if library.SkipOnClasspath {
@@ -204,13 +386,15 @@ func Prepare(inst *Instance, options LaunchOptions, watcher EventWatcher) (Launc
 	if options.CustomJar != "" {
 		launchEnv.Classpath = append(launchEnv.Classpath, options.CustomJar)
 	}
+
+	launchEnv.JavaArgs, launchEnv.GameArgs = createArgs(launchEnv, version, options, inst.NativesDir(), gameAssets, logConfig)
 	return launchEnv, nil
 }
 
 // download takes a list of download entries and executes them, reporting download events to watcher.
 //
-// It also creates all symlinks specified.
-func download(entries []network.DownloadEntry, symlinks map[string]string, watcher EventWatcher) error {
+// It also creates all symlinks specified. Remaining downloads are stopped if one fails or ctx is done.
+func download(ctx context.Context, entries []network.DownloadEntry, symlinks map[string]string, watcher EventWatcher) error {
 	for link, target := range symlinks {
 		if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
 			return fmt.Errorf("create directory for symlink %q: %w", link, err)
@@ -220,104 +404,57 @@ func download(entries []network.DownloadEntry, symlinks map[string]string, watch
 		}
 	}
 	if len(entries) > 0 {
-		results := network.StartDownloadEntries(entries)
-		i := 0
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		progress := newDownloadProgress(entries, watcher)
+		defer progress.stop()
+
+		results := network.StartDownloadEntriesContext(ctx, entries, progress.watch)
 		for err := range results {
 			if err != nil {
 				return err
 			}
-			watcher(DownloadingEvent{
-				Completed: i,
-				Total:     len(entries),
-			})
-			i++
+		}
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-// createArgs takes data from a launch environment, version metadata, and environment options to
-// create a set of game and Java arguments to pass when starting the game.
-func createArgs(launchEnv LaunchEnvironment, version meta.VersionMeta, options LaunchOptions, nativesDir string) (java, game []string) {
-	// Game arguments
-	game = []string{
-		"--username", options.Session.Username,
-		"--accessToken", options.Session.AccessToken,
-		"--userType", "msa",
-		"--gameDir", launchEnv.GameDir,
-		"--assetsDir", env.AssetsDir,
-		"--assetIndex", version.AssetIndex.ID,
-		"--version", version.ID,
-		"--versionType", version.Type,
-	}
-
-	gameOptions, _ := os.ReadFile(filepath.Join(launchEnv.GameDir, "options.txt"))
-	if !strings.Contains(string(gameOptions), "fullscreen:true") {
-		game = append(game, "--width", strconv.Itoa(options.WindowResolution.Width))
-		game = append(game, "--height", strconv.Itoa(options.WindowResolution.Height))
-	}
-
-	switch {
-	case options.QuickPlayServer != "":
-		game = append(game, "--quickPlayMultiplayer", options.QuickPlayServer)
-	case options.QuickPlayWorld != "":
-		game = append(game, "--quickPlaySingleplayer", options.QuickPlayWorld)
-	}
-	if options.Session.UUID != "" {
-		game = append(game, "--uuid", options.Session.UUID)
-	}
-	if options.Demo {
-		game = append(game, "--demo")
-	}
-	if options.DisableChat {
-		game = append(game, "--disableChat")
-	}
-	if options.DisableMultiplayer {
-		game = append(game, "--disableMultiplayer")
-	}
-
-	// Java arguments
-	if runtime.GOOS == "darwin" {
-		java = append(java, "-XstartOnFirstThread")
-	}
-	if options.MinMemory != 0 {
-		java = append(java, fmt.Sprintf("-Xms%dm", options.MinMemory))
-	}
-	if options.MaxMemory != 0 {
-		java = append(java, fmt.Sprintf("-Xmx%dm", options.MaxMemory))
-	}
-	if options.JavaArgs != "" {
-		java = append(java, strings.Split(options.JavaArgs, " ")...)
-	}
-	for _, arg := range version.Arguments.Game {
-		if arg, ok := arg.(string); ok {
-			game = append(game, arg)
-		}
+// downloadAll downloads entries like download, but keeps going when single downloads fail.
+//
+// The errors of failed downloads are returned by the path of their entry. An error is only returned if ctx is done.
+func downloadAll(ctx context.Context, entries []network.DownloadEntry, watcher EventWatcher) (failed map[string]error, err error) {
+	failed = make(map[string]error)
+	if len(entries) == 0 {
+		return failed, nil
 	}
-	for _, arg := range version.Arguments.Jvm {
-		// Replace any templates
-		if arg, ok := arg.(string); ok {
-			arg = strings.ReplaceAll(arg, "${version_name}", version.ID)
-			arg = strings.ReplaceAll(arg, "${library_directory}", env.LibrariesDir)
-			arg = strings.ReplaceAll(arg, "${classpath_separator}", string(os.PathListSeparator))
-			java = append(java, arg)
+	progress := newDownloadProgress(entries, watcher)
+	defer progress.stop()
+
+	var mu sync.Mutex
+	results := network.StartDownloadEntriesContext(ctx, entries, func(event any) {
+		if e, ok := event.(network.DownloadFinishedEvent); ok && e.Err != nil {
+			mu.Lock()
+			failed[e.Entry.Path] = e.Err
+			mu.Unlock()
 		}
+		progress.watch(event)
+	})
+	for range results {
 	}
-
-	// Legacy LWJGL 2 (pre-1.13) requires natives extracted into a directory.
-	// Inject java.library.path only when such JARs are present in the resolved set.
-	if nativesDir != "" {
-		java = append(java, fmt.Sprintf("-Djava.library.path=%s", nativesDir))
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-
-	return java, game
+	return failed, nil
 }
 
 // postProcess takes all Forge post processors and runs them with specified launch environment.
-func postProcess(launchEnv LaunchEnvironment, processors []meta.ForgeProcessor) error {
+func postProcess(ctx context.Context, launchEnv LaunchEnvironment, processors []meta.ForgeProcessor) error {
 	for _, processor := range processors {
-		cmd := exec.Command(launchEnv.Java, processor.JavaArgs...)
-		cmd.Dir = launchEnv.GameDir
+		cmd := launchEnv.javaCommand(ctx, processor.JavaArgs...)
 		cmd.Stderr = os.Stdout
 		if err := cmd.Run(); err != nil {
 			return err
@@ -325,3 +462,37 @@ func postProcess(launchEnv LaunchEnvironment, processors []meta.ForgeProcessor)
 	}
 	return nil
 }
+
+// javaCommand returns a command which runs Java with args in the game directory,
+// with the wrapper and environment variables of the launch environment applied.
+func (launchEnv LaunchEnvironment) javaCommand(ctx context.Context, args ...string) *exec.Cmd {
+	name := launchEnv.Java
+	if len(launchEnv.Wrapper) > 0 {
+		name = launchEnv.Wrapper[0]
+		args = append(append(slices.Clone(launchEnv.Wrapper[1:]), launchEnv.Java), args...)
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = launchEnv.GameDir
+	if len(launchEnv.Env) > 0 || len(launchEnv.UnsetEnv) > 0 {
+		cmd.Env = environ(launchEnv.Env, launchEnv.UnsetEnv)
+	}
+	return cmd
+}
+
+// environ returns the environment of the launcher with the variables in set added or replaced, and those in unset removed.
+func environ(set map[string]string, unset []string) []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		_, replaced := set[key]
+		if !replaced && !slices.Contains(unset, key) {
+			env = append(env, kv)
+		}
+	}
+	for key, value := range set {
+		if !slices.Contains(unset, key) {
+			env = append(env, key+"="+value)
+		}
+	}
+	return env
+}
@@ -1,13 +1,33 @@
 package launcher
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/telecter/cmd-launcher/internal/meta"
+	"github.com/telecter/cmd-launcher/internal/network"
 	env "github.com/telecter/cmd-launcher/pkg"
 	"github.com/telecter/cmd-launcher/pkg/auth"
 )
@@ -164,6 +184,270 @@ func TestCreateInstance(t *testing.T) {
 	}
 }
 
+// A standIn is a local server which stands in for Mojang's servers. It serves a version manifest
+// containing a single version, "1.0-test", along with its asset index, client JAR and log4j configuration.
+type standIn struct {
+	*httptest.Server
+	requests int
+}
+
+// sha1Hex returns the hex-encoded SHA1 checksum of data.
+func sha1Hex(data []byte) string {
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// newStandIn starts a standIn and routes requests for Mojang's metadata and downloads to it.
+func newStandIn(t *testing.T) *standIn {
+	client := []byte("not actually a JAR")
+	assetIndex := []byte(`{"objects": {}}`)
+	logConfig := []byte(`<Configuration status="WARN"></Configuration>`)
+	versionMeta := []byte(fmt.Sprintf(`{
+		"id": "1.0-test",
+		"type": "release",
+		"mainClass": "net.minecraft.client.main.Main",
+		"assetIndex": {"id": "test", "sha1": "%s", "url": "https://piston-meta.mojang.com/v1/packages/%[1]s/test.json"},
+		"downloads": {"client": {"sha1": "%s", "size": %d, "url": "https://piston-data.mojang.com/v1/objects/%[2]s/client.jar"}},
+		"logging": {"client": {
+			"argument": "-Dlog4j.configurationFile=${path}",
+			"file": {"id": "client-test.xml", "sha1": "%[4]s", "size": %[5]d, "url": "https://piston-data.mojang.com/v1/objects/%[4]s/client-test.xml"},
+			"type": "log4j2-xml"
+		}}
+	}`, sha1Hex(assetIndex), sha1Hex(client), len(client), sha1Hex(logConfig), len(logConfig)))
+	manifest := []byte(fmt.Sprintf(`{
+		"latest": {"release": "1.0-test", "snapshot": "1.0-test"},
+		"versions": [{"id": "1.0-test", "type": "release", "url": "https://piston-meta.mojang.com/v1/packages/%s/1.0-test.json", "sha1": "%[1]s"}]
+	}`, sha1Hex(versionMeta)))
+	java := []byte("#!/bin/sh\n")
+	javaManifest := []byte(fmt.Sprintf(`{"files": {
+		"bin": {"type": "directory"},
+		"bin/java": {"type": "file", "executable": true, "downloads": {"raw": {"sha1": "%s", "size": %d, "url": "https://piston-data.mojang.com/v1/objects/%[1]s/java"}}},
+		"bin/java-link": {"type": "link", "target": "java"}
+	}}`, sha1Hex(java), len(java)))
+	javaList := []byte(fmt.Sprintf(`{%q: {
+		"java-runtime-test": [{"manifest": {"sha1": "%s", "size": %d, "url": "https://piston-meta.mojang.com/v1/packages/%[2]s/manifest.json"}, "version": {"name": "21.0.1", "released": "2024-01-01T00:00:00+00:00"}}],
+		"jre-legacy": []
+	}}`, meta.JavaPlatform(), sha1Hex(javaManifest), len(javaManifest)))
+
+	files := map[string][]byte{
+		"/mc/game/version_manifest_v2.json":                                        manifest,
+		"/v1/packages/" + sha1Hex(versionMeta) + "/1.0-test.json":                  versionMeta,
+		"/v1/packages/" + sha1Hex(assetIndex) + "/test.json":                       assetIndex,
+		"/v1/objects/" + sha1Hex(client) + "/client.jar":                           client,
+		"/v1/objects/" + sha1Hex(logConfig) + "/client-test.xml":                   logConfig,
+		"/v1/packages/" + sha1Hex(javaManifest) + "/manifest.json":                 javaManifest,
+		"/v1/objects/" + sha1Hex(java) + "/java":                                   java,
+		strings.TrimPrefix(meta.JavaRuntimesURL, "https://piston-meta.mojang.com"): javaList,
+	}
+	s := &standIn{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests++
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	SetMirrors(
+		Mirror{Upstream: "https://piston-meta.mojang.com", Bases: []string{s.URL}, Exclusive: true},
+		Mirror{Upstream: "https://piston-data.mojang.com", Bases: []string{s.URL}, Exclusive: true},
+	)
+	t.Cleanup(func() {
+		SetMirrors()
+		s.Close()
+	})
+	return s
+}
+
+func TestCreateInstance_Mirror(t *testing.T) {
+	env.SetDirs(t.TempDir())
+	newStandIn(t)
+
+	inst, err := CreateInstance(InstanceOptions{
+		Name:        uuid.NewString(),
+		GameVersion: "release",
+		Loader:      meta.LoaderVanilla,
+	})
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if inst.GameVersion != "1.0-test" {
+		t.Errorf("wanted game version 1.0-test from mirror; got %q", inst.GameVersion)
+	}
+}
+
+func TestPrepare_Offline(t *testing.T) {
+	env.SetDirs(t.TempDir())
+	server := newStandIn(t)
+
+	inst, err := CreateInstance(InstanceOptions{
+		Name:        uuid.NewString(),
+		GameVersion: "release",
+		Loader:      meta.LoaderVanilla,
+		Config:      InstanceConfig{Java: "java"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating instance for test: %s", err)
+	}
+	options := LaunchOptions{
+		Session:        auth.Session{Username: "testing"},
+		InstanceConfig: inst.Config,
+		Offline:        true,
+	}
+
+	requests := server.requests
+	_, err = Prepare(&inst, options, testingWatcher)
+	if !errors.Is(err, ErrOffline) {
+		t.Errorf("wanted offline error before preparing online; got: %v", err)
+	}
+	if server.requests != requests {
+		t.Errorf("wanted no requests in offline mode; got %d", server.requests-requests)
+	}
+
+	options.Offline = false
+	if _, err := Prepare(&inst, options, testingWatcher); err != nil {
+		t.Fatalf("wanted no error preparing online; got: %s", err)
+	}
+
+	options.Offline = true
+	requests = server.requests
+	if _, err := Prepare(&inst, options, testingWatcher); err != nil {
+		t.Errorf("wanted no error preparing offline after preparing online; got: %s", err)
+	}
+	if server.requests != requests {
+		t.Errorf("wanted no requests in offline mode; got %d", server.requests-requests)
+	}
+}
+
+func TestPrepare_LogConfig(t *testing.T) {
+	env.SetDirs(t.TempDir())
+	newStandIn(t)
+
+	inst, err := CreateInstance(InstanceOptions{
+		Name:        uuid.NewString(),
+		GameVersion: "release",
+		Loader:      meta.LoaderVanilla,
+		Config:      InstanceConfig{Java: "java"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating instance for test: %s", err)
+	}
+	custom := filepath.Join(inst.Dir(), "log4j2.xml")
+	if err := os.WriteFile(custom, []byte("<Configuration></Configuration>"), 0644); err != nil {
+		t.Fatalf("unexpected error writing file for test: %s", err)
+	}
+
+	tests := []struct {
+		name      string
+		logConfig string
+		want      string
+	}{
+		{"Mojang", "", "-Dlog4j.configurationFile=" + meta.LoggingConfigPath("client-test.xml")},
+		{"None", LogConfigNone, ""},
+		{"Custom", "log4j2.xml", "-Dlog4j.configurationFile=" + custom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := inst.Config
+			config.LogConfig = tt.logConfig
+			launchEnv, err := Prepare(&inst, LaunchOptions{
+				Session:        auth.Session{Username: "testing"},
+				InstanceConfig: config,
+			}, testingWatcher)
+			if err != nil {
+				t.Fatalf("wanted no error; got: %s", err)
+			}
+			i := slices.IndexFunc(launchEnv.JavaArgs, func(arg string) bool {
+				return strings.HasPrefix(arg, "-Dlog4j.configurationFile=")
+			})
+			var got string
+			if i >= 0 {
+				got = launchEnv.JavaArgs[i]
+			}
+			if got != tt.want {
+				t.Errorf("wanted log configuration argument %q; got %q", tt.want, got)
+			}
+		})
+	}
+	if _, err := os.Stat(meta.LoggingConfigPath("client-test.xml")); err != nil {
+		t.Errorf("wanted log configuration to be downloaded; got: %s", err)
+	}
+}
+
+func TestStorage(t *testing.T) {
+	env.SetDirs(t.TempDir())
+	newStandIn(t)
+
+	inst, err := CreateInstance(InstanceOptions{
+		Name:        uuid.NewString(),
+		GameVersion: "release",
+		Loader:      meta.LoaderVanilla,
+		Config:      InstanceConfig{Java: "java"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating instance for test: %s", err)
+	}
+	options := LaunchOptions{
+		Session:        auth.Session{Username: "testing"},
+		InstanceConfig: inst.Config,
+	}
+	if _, err := Prepare(&inst, options, testingWatcher); err != nil {
+		t.Fatalf("unexpected error preparing instance for test: %s", err)
+	}
+
+	strays := []string{
+		filepath.Join(env.LibrariesDir, "org", "example", "old", "1.0", "old-1.0.jar"),
+		filepath.Join(env.AssetsDir, "objects", "ab", "abcdef"),
+		filepath.Join(env.AssetsDir, "virtual", "legacy", "sound", "click.ogg"),
+		filepath.Join(env.CachesDir, "minecraft", "0.9-test.json"),
+	}
+	// Legacy assets of the asset index of the instance are used
+	used := filepath.Join(env.AssetsDir, "virtual", "test", "icon.png")
+	for _, path := range append(strays, used) {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte("unused"), 0644); err != nil {
+			t.Fatalf("unexpected error writing file for test: %s", err)
+		}
+	}
+
+	unused, err := FetchUnusedStorage(context.Background())
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	var paths []string
+	for _, file := range unused {
+		paths = append(paths, file.Path)
+	}
+	if !slices.Equal(paths, strays) {
+		t.Errorf("wanted unused files %q; got %q", strays, paths)
+	}
+
+	// The stray asset object does not match its name
+	invalid, err := VerifyStorage(context.Background())
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if len(invalid) != 1 || invalid[0].Path != strays[1] {
+		t.Errorf("wanted only %q to be invalid; got %v", strays[1], invalid)
+	}
+
+	if err := RemoveStorage(unused); err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(env.LibrariesDir, "org", "example")); err == nil {
+		t.Error("wanted empty directories to be removed; but were not")
+	}
+	for _, path := range []string{meta.VersionMetaPath("1.0-test"), used} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("wanted used files to remain; got: %s", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(env.AssetsDir, "virtual", "legacy")); err == nil {
+		t.Error("wanted unused legacy assets to be removed; but were not")
+	}
+}
+
 func TestFetchAllInstances(t *testing.T) {
 	env.SetDirs(t.TempDir())
 	_, err := CreateInstance(InstanceOptions{
@@ -234,6 +518,46 @@ func testingWatcher(event any) {
 	}
 }
 
+func TestDownload_Progress(t *testing.T) {
+	data := bytes.Repeat([]byte{1}, 100_000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	entries := []network.DownloadEntry{
+		{URL: server.URL, Path: filepath.Join(dir, "a"), Size: int64(len(data))},
+		{URL: server.URL, Path: filepath.Join(dir, "b"), Size: int64(len(data))},
+		{URL: server.URL, Path: filepath.Join(dir, "c")},
+	}
+
+	var last DownloadingEvent
+	started, finished := 0, 0
+	err := download(context.Background(), entries, nil, func(event any) {
+		switch e := event.(type) {
+		case DownloadingEvent:
+			last = e
+		case DownloadStartedEvent:
+			started++
+		case DownloadFinishedEvent:
+			finished++
+		}
+	})
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if started != 3 || finished != 3 {
+		t.Errorf("wanted 3 started and finished events; got %d and %d", started, finished)
+	}
+	if last.Completed != 3 || last.Total != 3 {
+		t.Errorf("wanted 3/3 files completed; got %d/%d", last.Completed, last.Total)
+	}
+	if want := int64(3 * len(data)); last.BytesCompleted != want || last.BytesTotal != want {
+		t.Errorf("wanted %d/%d bytes completed; got %d/%d", want, want, last.BytesCompleted, last.BytesTotal)
+	}
+}
+
 func TestPrepare(t *testing.T) {
 	env.SetDirs(t.TempDir())
 
@@ -325,3 +649,1891 @@ func TestPrepare(t *testing.T) {
 		})
 	}
 }
+
+// readVersionMeta reads version metadata from the test data of the meta package.
+func readVersionMeta(t *testing.T, id string) meta.VersionMeta {
+	data, err := os.ReadFile(filepath.Join("..", "..", "internal", "meta", "testdata", id+".json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading test data: %s", err)
+	}
+	var version meta.VersionMeta
+	if err := json.Unmarshal(data, &version); err != nil {
+		t.Fatalf("unexpected error parsing test data: %s", err)
+	}
+	return version
+}
+
+func TestCreateArgs(t *testing.T) {
+	env.SetDirs(t.TempDir())
+	var resolution InstanceConfig
+	resolution.WindowResolution.Width, resolution.WindowResolution.Height = 854, 480
+
+	tests := []struct {
+		name     string
+		version  string
+		options  LaunchOptions
+		wantJava []string
+		wantGame []string
+	}{
+		{
+			name:     "Legacy",
+			version:  "1.12.2",
+			options:  LaunchOptions{Session: auth.Session{Username: "Notch", AccessToken: "token"}},
+			wantJava: []string{"-Djava.library.path=natives", "-Dminecraft.launcher.brand=cmd-launcher"},
+			wantGame: []string{
+				"--username", "Notch",
+				"--version", "1.12.2",
+				"--gameDir", "game",
+				"--assetsDir", env.AssetsDir,
+				"--assetIndex", "",
+				"--uuid", "b50ad385-829d-3141-a216-7e7d7539ba7f",
+				"--accessToken", "token",
+				"--userType", "msa",
+				"--versionType", "release",
+			},
+		},
+		{
+			name:    "Legacy Resolution",
+			version: "1.12.2",
+			options: LaunchOptions{
+				Session:        auth.Session{Username: "Notch", UUID: "069a79f444e94726a5befca90e38aaf5"},
+				InstanceConfig: resolution,
+				Demo:           true,
+			},
+			wantGame: []string{"--uuid", "069a79f444e94726a5befca90e38aaf5", "--demo", "--width", "854", "--height", "480"},
+		},
+		{
+			name:     "Modern",
+			version:  "1.20.1",
+			options:  LaunchOptions{Session: auth.Session{Username: "Notch"}, QuickPlayServer: "example.com"},
+			wantJava: []string{"-Djava.library.path=natives", "-Dminecraft.launcher.brand=cmd-launcher"},
+			wantGame: []string{"--username", "Notch", "--version", "1.20.1", "--quickPlayMultiplayer", "example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version := readVersionMeta(t, tt.version)
+			launchEnv := LaunchEnvironment{GameDir: "game", Classpath: []string{"a.jar", "b.jar"}}
+			java, game := createArgs(launchEnv, version, tt.options, "natives", env.AssetsDir, "")
+
+			for _, arg := range append(java, game...) {
+				if strings.Contains(arg, "${") || arg == "-cp" {
+					t.Errorf("wanted all placeholders and the classpath to be removed; got %q", arg)
+				}
+			}
+			if !containsSeq(java, tt.wantJava) {
+				t.Errorf("wanted Java arguments to contain %q; got %q", tt.wantJava, java)
+			}
+			if !containsSeq(game, tt.wantGame) {
+				t.Errorf("wanted game arguments to contain %q; got %q", tt.wantGame, game)
+			}
+		})
+	}
+}
+
+// containsSeq reports whether each value of want is in s, in order.
+func containsSeq(s, want []string) bool {
+	for _, w := range want {
+		i := slices.Index(s, w)
+		if i < 0 {
+			return false
+		}
+		s = s[i+1:]
+	}
+	return true
+}
+
+func TestExpandPlaceholders(t *testing.T) {
+	values := map[string]string{"version_name": "1.21", "classpath_separator": ":"}
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"${version_name}", "1.21"},
+		{"-Dversion=${version_name}", "-Dversion=1.21"},
+		{"a.jar${classpath_separator}b.jar", "a.jar:b.jar"},
+		{"${unknown}", "${unknown}"},
+		{"$version_name", "$version_name"},
+	}
+	for _, tt := range tests {
+		if got := expandPlaceholders(tt.arg, values); got != tt.want {
+			t.Errorf("expandPlaceholders(%q) = %q; want %q", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestScanLog(t *testing.T) {
+	output := `Starting game
+<log4j:Event logger="net.minecraft.client.Minecraft" timestamp="1690000000000" level="INFO" thread="Render thread">
+  <log4j:Message><![CDATA[Setting user: Notch]]></log4j:Message>
+</log4j:Event>
+<log4j:Event logger="net.minecraft.server.MinecraftServer" timestamp="1690000001000" level="ERROR" thread="Server thread">
+  <log4j:Message><![CDATA[Encountered an unexpected exception]]></log4j:Message>
+  <log4j:Throwable><![CDATA[java.lang.IllegalStateException: <test>
+	at net.minecraft.server.MinecraftServer.run(MinecraftServer.java:1)
+]]></log4j:Throwable>
+</log4j:Event>
+<log4j:Event logger="incomplete"
+`
+	var events []LogEvent
+	if err := scanLog(strings.NewReader(output), false, func(e LogEvent) {
+		events = append(events, e)
+	}); err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("wanted 4 events; got %d: %v", len(events), events)
+	}
+	if events[0].Message != "Starting game" || events[0].Level != "" {
+		t.Errorf("wanted plain output as message; got %+v", events[0])
+	}
+	want := LogEvent{
+		Time:    time.UnixMilli(1690000000000),
+		Level:   "INFO",
+		Thread:  "Render thread",
+		Logger:  "net.minecraft.client.Minecraft",
+		Message: "Setting user: Notch",
+	}
+	if events[1] != want {
+		t.Errorf("wanted %+v; got %+v", want, events[1])
+	}
+	if events[2].Level != "ERROR" || !strings.HasPrefix(events[2].Throwable, "java.lang.IllegalStateException: <test>\n") || strings.HasSuffix(events[2].Throwable, "\n") {
+		t.Errorf("wanted error with stack trace; got %+v", events[2])
+	}
+	if events[3].Message != `<log4j:Event logger="incomplete"` {
+		t.Errorf("wanted incomplete event as message; got %+v", events[3])
+	}
+
+	t.Run("LongLine", func(t *testing.T) {
+		long := strings.Repeat("x", maxLogLine*2+10)
+		var messages []string
+		if err := scanLog(strings.NewReader(long+"\nafter\n"), false, func(e LogEvent) {
+			messages = append(messages, e.Message)
+		}); err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		if len(messages) != 4 || strings.Join(messages[:3], "") != long || messages[3] != "after" {
+			t.Errorf("wanted long line to be split and output after it to be read; got %d messages", len(messages))
+		}
+	})
+}
+
+func TestLogRunner(t *testing.T) {
+	if os.Getenv("CMD_LAUNCHER_TEST_GAME") == "1" {
+		fmt.Println(`<log4j:Event logger="test" timestamp="0" level="WARN" thread="main"><log4j:Message><![CDATA[hello]]></log4j:Message></log4j:Event>`)
+		fmt.Fprintln(os.Stderr, "plain error")
+		os.Exit(0)
+	}
+	dir := t.TempDir()
+	logs := filepath.Join(dir, "logs")
+	os.MkdirAll(logs, 0755)
+	for i := range MaxLogFiles {
+		name := "launcher.log"
+		if i > 0 {
+			name = fmt.Sprintf("launcher.%d.log", i)
+		}
+		os.WriteFile(filepath.Join(logs, name), []byte(strconv.Itoa(i)), 0644)
+	}
+
+	var events []LogEvent
+	var mu sync.Mutex
+	runner := LogRunner(func(event any) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event.(LogEvent))
+	})
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLogRunner$")
+	cmd.Env = append(os.Environ(), "CMD_LAUNCHER_TEST_GAME=1")
+	cmd.Dir = dir
+	if err := runner(cmd); err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("wanted 2 events; got %v", events)
+	}
+	data, err := os.ReadFile(filepath.Join(logs, "launcher.log"))
+	if err != nil {
+		t.Fatalf("wanted log file to be written; got: %s", err)
+	}
+	if !strings.Contains(string(data), "[main/WARN]: hello\n") || !strings.Contains(string(data), "plain error\n") {
+		t.Errorf("wanted log file to contain all messages; got %q", data)
+	}
+	data, _ = os.ReadFile(filepath.Join(logs, "launcher.1.log"))
+	if string(data) != "0" {
+		t.Errorf("wanted previous log to be rotated; got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(logs, fmt.Sprintf("launcher.%d.log", MaxLogFiles))); err == nil {
+		t.Errorf("wanted at most %d log files", MaxLogFiles)
+	}
+}
+
+func TestDetectCrash(t *testing.T) {
+	if os.Getenv("CMD_LAUNCHER_TEST_GAME") == "crash" {
+		os.Exit(255)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestDetectCrash$")
+	cmd.Env = append(os.Environ(), "CMD_LAUNCHER_TEST_GAME=crash")
+	exitErr := cmd.Run()
+
+	report := `---- Minecraft Crash Report ----
+// Don't be sad, have a hug! <3
+
+Time: 2024-01-01 12:00:00
+Description: Ticking entity
+
+java.lang.NullPointerException: Cannot invoke "Object.toString()" because "value" is null
+	at com.example.broken.Entity.tick(Entity.java:42)
+
+A detailed walkthrough of the error, its code path and all known details is as follows:
+---------------------------------------------------------------------------------------
+
+-- Head --
+Thread: Server thread
+Suspected Mod: 
+	Broken Mod (broken), Version: 1.0
+`
+	jvmLog := `#
+# A fatal error has been detected by the Java Runtime Environment:
+#
+#  SIGSEGV (0xb) at pc=0x00007f, pid=1234, tid=5678
+#
+`
+	tests := []struct {
+		name      string
+		files     map[string]string
+		err       error
+		want      *CrashError
+		wantError bool
+	}{
+		{"Exit", nil, nil, nil, false},
+		{"Other error", nil, errors.New("runner failed"), nil, true},
+		{"Abnormal exit", nil, exitErr, &CrashError{ExitCode: 255}, true},
+		{
+			name:  "Crash report",
+			files: map[string]string{"crash-reports/crash-2024-01-01_12.00.00-server.txt": report},
+			err:   exitErr,
+			want: &CrashError{
+				ExitCode:  255,
+				Report:    "crash-reports/crash-2024-01-01_12.00.00-server.txt",
+				Exception: `java.lang.NullPointerException: Cannot invoke "Object.toString()" because "value" is null`,
+				Mod:       "Broken Mod (broken), Version: 1.0",
+			},
+			wantError: true,
+		},
+		{
+			name:      "Crash report after exit",
+			files:     map[string]string{"crash-reports/crash-2024-01-01_12.00.00-client.txt": "Description: Test\n\njava.lang.Error\n\tSuspected Mods: NONE\n"},
+			want:      &CrashError{Report: "crash-reports/crash-2024-01-01_12.00.00-client.txt", Exception: "java.lang.Error"},
+			wantError: true,
+		},
+		{
+			name:      "JVM crash",
+			files:     map[string]string{"hs_err_pid1234.log": jvmLog},
+			err:       exitErr,
+			want:      &CrashError{ExitCode: 255, Report: "hs_err_pid1234.log", Exception: "SIGSEGV (0xb) at pc=0x00007f, pid=1234, tid=5678"},
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				os.MkdirAll(filepath.Dir(path), 0755)
+				os.WriteFile(path, []byte(content), 0644)
+			}
+			// An old crash report is not from this launch
+			old := filepath.Join(dir, "crash-reports", "crash-2000-01-01_00.00.00-client.txt")
+			os.MkdirAll(filepath.Dir(old), 0755)
+			os.WriteFile(old, []byte("Description: Old\n\njava.lang.Error: old\n"), 0644)
+			os.Chtimes(old, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))
+
+			err := detectCrash(dir, time.Now().Add(-time.Minute), tt.err)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("wanted error: %t; got: %v", tt.wantError, err)
+			}
+			var crash *CrashError
+			if !errors.As(err, &crash) {
+				if tt.want != nil {
+					t.Fatalf("wanted crash error; got: %v", err)
+				}
+				return
+			}
+			if tt.want == nil {
+				t.Fatalf("wanted no crash error; got: %v", err)
+			}
+			if tt.want.Report != "" {
+				tt.want.Report = filepath.Join(dir, tt.want.Report)
+			}
+			tt.want.Err = tt.err
+			if *crash != *tt.want {
+				t.Errorf("wanted %+v; got %+v", tt.want, crash)
+			}
+		})
+	}
+}
+
+func TestLaunch_History(t *testing.T) {
+	env.SetDirs(t.TempDir())
+	inst := Instance{Name: "history", GameVersion: "1.21", Loader: meta.LoaderFabric, LoaderVersion: "0.16.0"}
+	os.MkdirAll(inst.Dir(), 0755)
+
+	launchEnv := LaunchEnvironment{
+		GameDir:       inst.Dir(),
+		Java:          os.Args[0],
+		Username:      "Notch",
+		GameVersion:   inst.GameVersion,
+		Loader:        inst.Loader,
+		LoaderVersion: inst.LoaderVersion,
+	}
+	if err := Launch(launchEnv, func(cmd *exec.Cmd) error { return nil }); err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	// Sessions where the game could not be started are not recorded
+	if err := Launch(launchEnv, func(cmd *exec.Cmd) error { return exec.ErrNotFound }); !errors.Is(err, exec.ErrNotFound) {
+		t.Fatalf("wanted runner error; got: %v", err)
+	}
+
+	history, err := inst.History()
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("wanted 1 session; got %d", len(history))
+	}
+	entry := history[0]
+	if entry.Username != "Notch" || entry.GameVersion != "1.21" || entry.Loader != meta.LoaderFabric || entry.LoaderVersion != "0.16.0" || entry.ExitCode != 0 {
+		t.Errorf("wanted session of Notch playing 1.21 with Fabric 0.16.0; got %+v", entry)
+	}
+	lastPlayed, total, err := inst.Playtime()
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if !lastPlayed.Equal(entry.Start) || total != entry.Duration() {
+		t.Errorf("wanted playtime to match session; got %s, %s", lastPlayed, total)
+	}
+}
+
+func TestLaunch_Hooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test are written for sh")
+	}
+	env.SetDirs(t.TempDir())
+	inst := Instance{Name: "hooks", GameVersion: "1.21", Loader: meta.LoaderVanilla}
+	os.MkdirAll(inst.Dir(), 0755)
+
+	launchEnv := LaunchEnvironment{
+		GameDir:      inst.Dir(),
+		Java:         os.Args[0],
+		InstanceName: inst.Name,
+		GameVersion:  inst.GameVersion,
+		Loader:       inst.Loader,
+		Hooks: Hooks{
+			PreLaunch: `echo "$INST_NAME $INST_MC_VERSION $INST_LOADER" > pre.txt`,
+			PostExit:  `echo "$INST_EXIT_CODE" > post.txt`,
+		},
+	}
+	var ran bool
+	runner := func(cmd *exec.Cmd) error {
+		ran = true
+		return nil
+	}
+	if err := Launch(launchEnv, runner); err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	for name, want := range map[string]string{"pre.txt": "hooks 1.21 vanilla\n", "post.txt": "0\n"} {
+		data, err := os.ReadFile(filepath.Join(inst.Dir(), name))
+		if err != nil {
+			t.Fatalf("wanted hook to write %s; got: %s", name, err)
+		}
+		if string(data) != want {
+			t.Errorf("wanted %s to contain %q; got %q", name, want, data)
+		}
+	}
+
+	ran = false
+	launchEnv.Hooks.PreLaunch = "exit 3"
+	err := Launch(launchEnv, runner)
+	var hookErr *HookError
+	if !errors.As(err, &hookErr) || hookErr.Hook != "pre_launch" {
+		t.Fatalf("wanted pre-launch hook error; got: %v", err)
+	}
+	if ran {
+		t.Error("wanted game not to be started after pre-launch hook failed")
+	}
+}
+
+func TestPrepare_Hooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test are written for sh")
+	}
+	env.SetDirs(t.TempDir())
+	server := newStandIn(t)
+
+	inst, err := CreateInstance(InstanceOptions{
+		Name:        uuid.NewString(),
+		GameVersion: "release",
+		Loader:      meta.LoaderVanilla,
+		Config:      InstanceConfig{Java: "java"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating instance for test: %s", err)
+	}
+	config := inst.Config
+	config.Hooks.PrePrepare = "exit 1"
+
+	requests := server.requests
+	_, err = Prepare(&inst, LaunchOptions{Session: auth.Session{Username: "testing"}, InstanceConfig: config}, testingWatcher)
+	var hookErr *HookError
+	if !errors.As(err, &hookErr) || hookErr.Hook != "pre_prepare" {
+		t.Fatalf("wanted pre-prepare hook error; got: %v", err)
+	}
+	if server.requests != requests {
+		t.Errorf("wanted nothing to be prepared after pre-prepare hook failed; got %d requests", server.requests-requests)
+	}
+
+	config.Hooks.PrePrepare = "true"
+	launchEnv, err := Prepare(&inst, LaunchOptions{Session: auth.Session{Username: "testing"}, InstanceConfig: config}, testingWatcher)
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if launchEnv.InstanceName != inst.Name || launchEnv.Hooks != config.Hooks {
+		t.Errorf("wanted launch environment to contain instance name and hooks; got %q, %+v", launchEnv.InstanceName, launchEnv.Hooks)
+	}
+}
+
+func TestLaunch_Wrapper(t *testing.T) {
+	t.Setenv("CMD_LAUNCHER_TEST_UNSET", "1")
+	t.Setenv("CMD_LAUNCHER_TEST_REPLACED", "old")
+	launchEnv := LaunchEnvironment{
+		GameDir:   t.TempDir(),
+		Java:      os.Args[0],
+		MainClass: "net.minecraft.client.main.Main",
+		Wrapper:   []string{"nice", "-n", "5"},
+		Env:       map[string]string{"CMD_LAUNCHER_TEST_REPLACED": "new", "CMD_LAUNCHER_TEST_SET": "1"},
+		UnsetEnv:  []string{"CMD_LAUNCHER_TEST_UNSET"},
+	}
+	var cmd *exec.Cmd
+	if err := Launch(launchEnv, func(c *exec.Cmd) error {
+		cmd = c
+		return nil
+	}); err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+
+	want := []string{"nice", "-n", "5", os.Args[0], "-cp", "", "net.minecraft.client.main.Main"}
+	if !slices.Equal(cmd.Args, want) {
+		t.Errorf("wanted command %q; got %q", want, cmd.Args)
+	}
+	for _, kv := range []string{"CMD_LAUNCHER_TEST_REPLACED=new", "CMD_LAUNCHER_TEST_SET=1"} {
+		if !slices.Contains(cmd.Env, kv) {
+			t.Errorf("wanted environment to contain %q", kv)
+		}
+	}
+	for _, kv := range cmd.Env {
+		if strings.HasPrefix(kv, "CMD_LAUNCHER_TEST_UNSET=") || kv == "CMD_LAUNCHER_TEST_REPLACED=old" {
+			t.Errorf("wanted %q to be removed from environment", kv)
+		}
+	}
+}
+
+func TestExportLaunch(t *testing.T) {
+	dir := t.TempDir()
+	launchEnv := LaunchEnvironment{
+		GameDir:   dir,
+		Java:      filepath.Join(dir, "java"),
+		MainClass: "net.minecraft.client.main.Main",
+		Classpath: []string{"a.jar", "it's.jar"},
+		JavaArgs:  []string{"-Xmx2048m"},
+		GameArgs:  []string{"--username", "Notch", "--accessToken", "secret", "--session", "token:secret:uuid"},
+		Env:       map[string]string{"CMD_LAUNCHER_TEST": "a b"},
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := ExportLaunchJSON(&buf, launchEnv, ExportOptions{AccessToken: "secret", TokenEnv: "MC_TOKEN"}); err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		if strings.Contains(buf.String(), "secret") {
+			t.Errorf("wanted access token to be removed; got %s", buf.String())
+		}
+
+		data := buf.Bytes()
+		if _, err := ImportLaunchJSON(bytes.NewReader(data)); err == nil {
+			t.Error("wanted error importing without token variable; got none")
+		}
+		t.Setenv("MC_TOKEN", "new")
+		imported, err := ImportLaunchJSON(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		want := []string{"--username", "Notch", "--accessToken", "new", "--session", "token:new:uuid"}
+		if !slices.Equal(imported.GameArgs, want) {
+			t.Errorf("wanted game arguments %q; got %q", want, imported.GameArgs)
+		}
+		if imported.MainClass != launchEnv.MainClass || !slices.Equal(imported.Classpath, launchEnv.Classpath) || imported.Env["CMD_LAUNCHER_TEST"] != "a b" {
+			t.Errorf("wanted imported launch environment to match; got %+v", imported)
+		}
+	})
+
+	t.Run("Redacted", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := ExportLaunchJSON(&buf, launchEnv, ExportOptions{AccessToken: "secret"}); err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		imported, err := ImportLaunchJSON(&buf)
+		if err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		if !slices.Contains(imported.GameArgs, RedactedToken) || slices.Contains(imported.GameArgs, "secret") {
+			t.Errorf("wanted access token to be redacted; got %q", imported.GameArgs)
+		}
+	})
+
+	t.Run("Script", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("exported scripts require a POSIX shell")
+		}
+		// A stand-in for Java which prints its arguments and environment
+		java := "#!/bin/sh\nprintf '%s\\n' \"$@\" \"$CMD_LAUNCHER_TEST\"\n"
+		if err := os.WriteFile(launchEnv.Java, []byte(java), 0755); err != nil {
+			t.Fatalf("unexpected error writing file for test: %s", err)
+		}
+		var buf bytes.Buffer
+		if err := ExportLaunchScript(&buf, launchEnv, ExportOptions{AccessToken: "secret", TokenEnv: "MC_TOKEN"}); err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		if strings.Contains(buf.String(), "secret") {
+			t.Errorf("wanted access token to be removed; got %s", buf.String())
+		}
+
+		cmd := exec.Command("sh", "-c", buf.String())
+		cmd.Env = append(os.Environ(), "MC_TOKEN=it's new")
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("wanted script to run; got: %s", err)
+		}
+		want := "-Xmx2048m\n-cp\na.jar:it's.jar\nnet.minecraft.client.main.Main\n--username\nNotch\n--accessToken\nit's new\n--session\ntoken:it's new:uuid\na b\n"
+		if string(out) != want {
+			t.Errorf("wanted output %q; got %q", want, out)
+		}
+
+		cmd = exec.Command("sh", "-c", buf.String())
+		cmd.Env = slices.DeleteFunc(os.Environ(), func(kv string) bool { return strings.HasPrefix(kv, "MC_TOKEN=") })
+		if err := cmd.Run(); err == nil {
+			t.Error("wanted script to fail without token variable; got no error")
+		}
+	})
+
+	t.Run("Offline", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := ExportLaunchJSON(&buf, launchEnv, ExportOptions{TokenEnv: "MC_TOKEN_UNSET"}); err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		if _, err := ImportLaunchJSON(&buf); err != nil {
+			t.Errorf("wanted no token variable to be required without an access token; got: %s", err)
+		}
+	})
+
+	t.Run("InvalidEnv", func(t *testing.T) {
+		for _, invalid := range []LaunchEnvironment{
+			{Env: map[string]string{"X; rm -rf ~": "a"}},
+			{UnsetEnv: []string{"X; rm -rf ~"}},
+		} {
+			var buf bytes.Buffer
+			if err := ExportLaunchScript(&buf, invalid, ExportOptions{}); err == nil {
+				t.Errorf("wanted error for invalid variable name; got script %q", buf.String())
+			}
+		}
+	})
+}
+
+// writeFakeJava writes a stand-in for a Java executable to home which reports the specified specification version.
+func writeFakeJava(t *testing.T, home, version string) string {
+	t.Helper()
+	java := javaExecutable(home)
+	// Only shell builtins are used, since PATH may not contain anything else
+	script := fmt.Sprintf("#!/bin/sh\nprintf '%%s\\n' 'Property settings:' '    java.specification.version = %[1]s' '    java.vendor = Test Vendor' '    java.version = %[1]s.0.1' '    os.arch = amd64' >&2\n", version)
+	if err := os.MkdirAll(filepath.Dir(java), 0755); err != nil {
+		t.Fatalf("unexpected error creating directory for test: %s", err)
+	}
+	if err := os.WriteFile(java, []byte(script), 0755); err != nil {
+		t.Fatalf("unexpected error writing file for test: %s", err)
+	}
+	return java
+}
+
+func TestParseJavaMajorVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    int
+	}{
+		{"1.8", 8},
+		{"1.8.0_402", 8},
+		{"17", 17},
+		{"21.0.2", 21},
+	}
+	for _, tt := range tests {
+		got, err := parseJavaMajorVersion(tt.version)
+		if err != nil {
+			t.Errorf("wanted no error for %q; got: %s", tt.version, err)
+		}
+		if got != tt.want {
+			t.Errorf("wanted major version %d for %q; got %d", tt.want, tt.version, got)
+		}
+	}
+	if _, err := parseJavaMajorVersion(""); err == nil {
+		t.Error("wanted error for empty version; got nil")
+	}
+}
+
+func TestFindJavaInstallations(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake Java executables require a POSIX shell")
+	}
+	dir := t.TempDir()
+	env.SetDirs(filepath.Join(dir, "launcher"))
+	t.Setenv("HOME", dir)
+	java17 := writeFakeJava(t, filepath.Join(dir, "jdk-17"), "17")
+	java8 := writeFakeJava(t, filepath.Join(dir, "jdk-8"), "1.8")
+	// Runtimes downloaded by the launcher are skipped
+	writeFakeJava(t, filepath.Join(env.JavaDir, "java-runtime-delta"), "21")
+	t.Setenv("JAVA_HOME", filepath.Join(dir, "jdk-17"))
+	t.Setenv("PATH", strings.Join([]string{filepath.Dir(java17), filepath.Dir(java8), filepath.Join(env.JavaDir, "java-runtime-delta", "bin")}, string(os.PathListSeparator)))
+
+	installations, err := FindJavaInstallations(context.Background())
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	var found []JavaInstallation
+	for _, installation := range installations {
+		if isWithin(dir, installation.Path) {
+			found = append(found, installation)
+		}
+	}
+	want := []JavaInstallation{
+		{Path: java17, Version: "17.0.1", MajorVersion: 17, Vendor: "Test Vendor", Arch: "amd64"},
+		{Path: java8, Version: "1.8.0.1", MajorVersion: 8, Vendor: "Test Vendor", Arch: "amd64"},
+	}
+	if !slices.Equal(found, want) {
+		t.Errorf("wanted installations %+v; got %+v", want, found)
+	}
+
+	installation, err := FindCompatibleJava(context.Background(), 8)
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if installation.Path != java8 {
+		t.Errorf("wanted Java 8 at %s; got %s", java8, installation.Path)
+	}
+	if _, err := FindCompatibleJava(context.Background(), 99); !errors.Is(err, ErrNoCompatibleJava) {
+		t.Errorf("wanted ErrNoCompatibleJava; got %v", err)
+	}
+}
+
+func TestPrepare_Java(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake Java executables require a POSIX shell")
+	}
+	dir := t.TempDir()
+	env.SetDirs(filepath.Join(dir, "launcher"))
+	newStandIn(t)
+	t.Setenv("HOME", dir)
+	t.Setenv("JAVA_HOME", "")
+	java8 := writeFakeJava(t, filepath.Join(dir, "jdk-8"), "1.8")
+	java17 := writeFakeJava(t, filepath.Join(dir, "jdk-17"), "17")
+	t.Setenv("PATH", filepath.Dir(java17)+string(os.PathListSeparator)+filepath.Dir(java8))
+
+	inst, err := CreateInstance(InstanceOptions{
+		Name:        uuid.NewString(),
+		GameVersion: "release",
+		Loader:      meta.LoaderVanilla,
+		Config:      InstanceConfig{Java: java17},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating instance for test: %s", err)
+	}
+	prepare := func(java string) (LaunchEnvironment, []any) {
+		t.Helper()
+		var events []any
+		config := inst.Config
+		config.Java = java
+		launchEnv, err := Prepare(&inst, LaunchOptions{
+			Session:        auth.Session{Username: "testing"},
+			InstanceConfig: config,
+			skipAssets:     true,
+		}, func(event any) { events = append(events, event) })
+		if err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		return launchEnv, events
+	}
+
+	// The stand-in version has no Java version, so Java 8 is required
+	launchEnv, events := prepare(java17)
+	if launchEnv.Java != java17 {
+		t.Errorf("wanted configured Java %s; got %s", java17, launchEnv.Java)
+	}
+	want := JavaVersionMismatchEvent{Path: java17, Required: 8, Found: 17}
+	if !slices.Contains(events, any(want)) {
+		t.Errorf("wanted %+v; got events %+v", want, events)
+	}
+
+	launchEnv, events = prepare(JavaSystem)
+	if launchEnv.Java != java8 {
+		t.Errorf("wanted system Java %s; got %s", java8, launchEnv.Java)
+	}
+	if !slices.ContainsFunc(events, func(event any) bool {
+		e, ok := event.(SystemJavaSelectedEvent)
+		return ok && e.Installation.Path == java8
+	}) {
+		t.Errorf("wanted SystemJavaSelectedEvent; got events %+v", events)
+	}
+}
+
+func TestJavaRuntimes(t *testing.T) {
+	env.SetDirs(t.TempDir())
+	newStandIn(t)
+	ctx := context.Background()
+
+	runtimes, err := FetchJavaRuntimes(ctx)
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	want := []JavaRuntime{
+		{Component: "java-runtime-test", Version: "21.0.1", Released: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Available: true},
+	}
+	if len(runtimes) != len(want) || runtimes[0].Component != want[0].Component || runtimes[0].Version != want[0].Version ||
+		!runtimes[0].Released.Equal(want[0].Released) || !runtimes[0].Available || runtimes[0].Installed {
+		t.Fatalf("wanted runtimes %+v; got %+v", want, runtimes)
+	}
+	javaRuntime := runtimes[0]
+
+	if err := InstallJavaRuntime(ctx, javaRuntime.Component, testingWatcher); err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if _, err := os.Stat(javaRuntime.Java()); err != nil {
+		t.Errorf("wanted Java executable to be installed; got: %s", err)
+	}
+	runtimes, err = FetchJavaRuntimes(ctx)
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if !runtimes[0].Installed || runtimes[0].Size != int64(len("#!/bin/sh\n")) {
+		t.Errorf("wanted installed runtime with size; got %+v", runtimes[0])
+	}
+
+	invalid, err := VerifyJavaRuntime(ctx, javaRuntime.Component)
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if len(invalid) != 0 {
+		t.Errorf("wanted no invalid files; got %+v", invalid)
+	}
+	if err := os.WriteFile(javaRuntime.Java(), []byte("corrupted"), 0755); err != nil {
+		t.Fatalf("unexpected error writing file for test: %s", err)
+	}
+	link := filepath.Join(javaRuntime.Dir(), "bin", "java-link")
+	if err := os.Remove(link); err != nil {
+		t.Fatalf("unexpected error removing file for test: %s", err)
+	}
+	invalid, err = VerifyJavaRuntime(ctx, javaRuntime.Component)
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if len(invalid) != 2 || invalid[0].Path != javaRuntime.Java() || invalid[1].Path != link {
+		t.Errorf("wanted corrupted and missing file to be invalid; got %+v", invalid)
+	}
+	if err := InstallJavaRuntime(ctx, javaRuntime.Component, testingWatcher); err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if invalid, _ := VerifyJavaRuntime(ctx, javaRuntime.Component); len(invalid) != 0 {
+		t.Errorf("wanted runtime to be repaired; got invalid files %+v", invalid)
+	}
+
+	if err := RemoveJavaRuntime(javaRuntime.Component); err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if _, err := os.Stat(javaRuntime.Dir()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("wanted runtime directory to be removed; got: %v", err)
+	}
+	if err := RemoveJavaRuntime(javaRuntime.Component); !errors.Is(err, ErrJavaRuntimeNotInstalled) {
+		t.Errorf("wanted ErrJavaRuntimeNotInstalled removing runtime which is not installed; got %v", err)
+	}
+	if invalid, err := VerifyJavaRuntime(ctx, javaRuntime.Component); !errors.Is(err, ErrJavaRuntimeNotInstalled) || len(invalid) != 0 {
+		t.Errorf("wanted ErrJavaRuntimeNotInstalled verifying runtime which is not installed; got %d files, %v", len(invalid), err)
+	}
+	if err := RemoveJavaRuntime("../instances"); err == nil {
+		t.Error("wanted error for invalid component; got nil")
+	}
+}
+
+// A modrinthStandIn is a local stand-in for the Modrinth API and CDN.
+type modrinthStandIn struct {
+	*httptest.Server
+	projects map[string]meta.ModrinthProject
+	versions []meta.ModrinthVersion
+	files    map[string][]byte
+	query    url.Values // Query of the last search
+	down     bool       // API requests fail, but files are still served
+}
+
+func newModrinthStandIn(t *testing.T) *modrinthStandIn {
+	s := &modrinthStandIn{projects: make(map[string]meta.ModrinthProject), files: make(map[string][]byte)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		var v any
+		switch {
+		case len(parts) >= 2 && parts[0] == "data":
+			data, ok := s.files[parts[len(parts)-1]]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(data)
+			return
+		case s.down:
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		case len(parts) == 2 && parts[1] == "search":
+			s.query = r.URL.Query()
+			var hits []meta.ModrinthSearchHit
+			for _, project := range s.projects {
+				hits = append(hits, meta.ModrinthSearchHit{ProjectID: project.ID, Slug: project.Slug, Title: project.Title})
+			}
+			v = map[string]any{"hits": hits}
+		case len(parts) == 3 && parts[1] == "project":
+			project, ok := s.project(parts[2])
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			v = project
+		case len(parts) == 4 && parts[1] == "project" && parts[3] == "version":
+			project, ok := s.project(parts[2])
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			var loaders, gameVersions []string
+			json.Unmarshal([]byte(r.URL.Query().Get("loaders")), &loaders)
+			json.Unmarshal([]byte(r.URL.Query().Get("game_versions")), &gameVersions)
+			versions := []meta.ModrinthVersion{}
+			// Newest versions come first
+			for _, version := range slices.Backward(s.versions) {
+				compatible := slices.ContainsFunc(version.Loaders, func(loader string) bool { return slices.Contains(loaders, loader) }) &&
+					slices.ContainsFunc(version.GameVersions, func(gameVersion string) bool { return slices.Contains(gameVersions, gameVersion) })
+				if version.ProjectID == project.ID && compatible {
+					versions = append(versions, version)
+				}
+			}
+			v = versions
+		case len(parts) == 2 && (parts[1] == "versions" || parts[1] == "projects"):
+			var ids []string
+			json.Unmarshal([]byte(r.URL.Query().Get("ids")), &ids)
+			if parts[1] == "projects" {
+				projects := []meta.ModrinthProject{}
+				for _, id := range ids {
+					if project, ok := s.project(id); ok {
+						projects = append(projects, project)
+					}
+				}
+				v = projects
+				break
+			}
+			versions := []meta.ModrinthVersion{}
+			for _, version := range s.versions {
+				if slices.Contains(ids, version.ID) {
+					versions = append(versions, version)
+				}
+			}
+			v = versions
+		case len(parts) == 3 && parts[1] == "version":
+			i := slices.IndexFunc(s.versions, func(version meta.ModrinthVersion) bool { return version.ID == parts[2] })
+			if i < 0 {
+				http.NotFound(w, r)
+				return
+			}
+			v = s.versions[i]
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(v)
+	}))
+	// Mirrors of other stand-ins are kept
+	mirrors := network.Mirrors()
+	SetMirrors(append(mirrors,
+		Mirror{Upstream: "https://api.modrinth.com", Bases: []string{s.URL}, Exclusive: true},
+		Mirror{Upstream: "https://cdn.modrinth.com", Bases: []string{s.URL}, Exclusive: true},
+	)...)
+	t.Cleanup(func() {
+		SetMirrors(mirrors...)
+		s.Close()
+	})
+	return s
+}
+
+func (s *modrinthStandIn) project(idOrSlug string) (meta.ModrinthProject, bool) {
+	for _, project := range s.projects {
+		if project.ID == idOrSlug || project.Slug == idOrSlug {
+			return project, true
+		}
+	}
+	return meta.ModrinthProject{}, false
+}
+
+// addProject adds a mod to the stand-in.
+func (s *modrinthStandIn) addProject(id, slug string) {
+	s.projects[id] = meta.ModrinthProject{ID: id, Slug: slug, Title: strings.ToUpper(slug[:1]) + slug[1:], ProjectType: "mod"}
+}
+
+// addVersion adds a version of a project for Fabric 1.20.1 to the stand-in, with a file containing its ID.
+func (s *modrinthStandIn) addVersion(projectID, id string, dependencies ...meta.ModrinthDependency) {
+	data := []byte(id)
+	file := meta.ModrinthFile{
+		URL:      "https://cdn.modrinth.com/data/" + projectID + "/versions/" + id + "/" + id + ".jar",
+		Filename: id + ".jar",
+		Primary:  true,
+		Size:     int64(len(data)),
+	}
+	file.Hashes.Sha1 = sha1Hex(data)
+	s.files[id+".jar"] = data
+	s.versions = append(s.versions, meta.ModrinthVersion{
+		ID:            id,
+		ProjectID:     projectID,
+		VersionNumber: id,
+		GameVersions:  []string{"1.20.1"},
+		Loaders:       []string{"fabric"},
+		Files:         []meta.ModrinthFile{file},
+		Dependencies:  dependencies,
+	})
+}
+
+// newModdedInstance creates a 1.20.1 instance with the specified loader, without retrieving any metadata.
+func newModdedInstance(t *testing.T, loader meta.Loader) Instance {
+	inst := Instance{Name: uuid.NewString(), GameVersion: "1.20.1", Loader: loader}
+	if err := os.MkdirAll(inst.Dir(), 0755); err != nil {
+		t.Fatalf("unexpected error creating instance for test: %s", err)
+	}
+	if err := inst.WriteConfig(); err != nil {
+		t.Fatalf("unexpected error creating instance for test: %s", err)
+	}
+	return inst
+}
+
+func TestMods(t *testing.T) {
+	env.SetDirs(t.TempDir())
+	s := newModrinthStandIn(t)
+	s.addProject("AANobbMI", "sodium")
+	s.addProject("P7dR8mSH", "fabric-api")
+	s.addProject("Orvt0mRa", "indium")
+	s.addProject("6AQIaxuO", "optifabric")
+	s.addProject("OLD00000", "legacy")
+	s.addVersion("AANobbMI", "sodium-1")
+	s.addVersion("P7dR8mSH", "fabric-api-1")
+	s.addVersion("Orvt0mRa", "indium-1",
+		meta.ModrinthDependency{ProjectID: "AANobbMI", DependencyType: "required"},
+		meta.ModrinthDependency{VersionID: "fabric-api-1", DependencyType: "required"},
+		meta.ModrinthDependency{ProjectID: "6AQIaxuO", DependencyType: "optional"},
+	)
+	s.addVersion("6AQIaxuO", "optifabric-1", meta.ModrinthDependency{ProjectID: "AANobbMI", DependencyType: "incompatible"})
+	inst := newModdedInstance(t, meta.LoaderFabric)
+	ctx := context.Background()
+
+	added, err := AddMods(ctx, inst, []string{"indium"}, testingWatcher)
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if len(added) != 3 {
+		t.Fatalf("wanted indium and its 2 required dependencies to be added; got %+v", added)
+	}
+	for _, mod := range added {
+		data, err := os.ReadFile(filepath.Join(inst.ModsDir(), mod.File))
+		if err != nil || string(data) != mod.VersionID {
+			t.Errorf("wanted file of %s to be downloaded; got %q, %v", mod.Slug, data, err)
+		}
+	}
+	mods, err := inst.Mods()
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	var slugs []string
+	for _, mod := range mods {
+		slugs = append(slugs, mod.Slug)
+		if mod.Dependency != (mod.Slug != "indium") {
+			t.Errorf("wanted only indium to not be a dependency; got %+v", mod)
+		}
+	}
+	if want := []string{"fabric-api", "indium", "sodium"}; !slices.Equal(slugs, want) {
+		t.Errorf("wanted mods %q in lockfile; got %q", want, slugs)
+	}
+
+	t.Run("Installed", func(t *testing.T) {
+		added, err := AddMods(ctx, inst, []string{"sodium"}, testingWatcher)
+		if err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		if len(added) != 0 {
+			t.Errorf("wanted installed mod to be skipped; got %+v", added)
+		}
+		mods, _ := inst.Mods()
+		if i := findMod(mods, "sodium"); i < 0 || mods[i].Dependency {
+			t.Errorf("wanted sodium to no longer be a dependency; got %+v", mods)
+		}
+	})
+	t.Run("Incompatible", func(t *testing.T) {
+		if _, err := AddMods(ctx, inst, []string{"optifabric"}, testingWatcher); err == nil {
+			t.Error("wanted error adding incompatible mod; got nil")
+		}
+		if _, err := AddMods(ctx, inst, []string{"legacy"}, testingWatcher); !errors.Is(err, ErrNoCompatibleMod) {
+			t.Errorf("wanted ErrNoCompatibleMod; got %v", err)
+		}
+		if _, err := AddMods(ctx, inst, []string{"missing"}, testingWatcher); !errors.Is(err, meta.ErrModrinthNotFound) {
+			t.Errorf("wanted ErrModrinthNotFound; got %v", err)
+		}
+		vanilla := newModdedInstance(t, meta.LoaderVanilla)
+		if _, err := AddMods(ctx, vanilla, []string{"sodium"}, testingWatcher); !errors.Is(err, ErrModsUnsupported) {
+			t.Errorf("wanted ErrModsUnsupported; got %v", err)
+		}
+	})
+	t.Run("Checksum", func(t *testing.T) {
+		s.addProject("BROKEN00", "broken")
+		s.addVersion("BROKEN00", "broken-1")
+		s.files["broken-1.jar"] = []byte("corrupted")
+		var checksumErr *network.ChecksumError
+		if _, err := AddMods(ctx, inst, []string{"broken"}, testingWatcher); !errors.As(err, &checksumErr) {
+			t.Errorf("wanted ChecksumError; got %v", err)
+		}
+		if mods, _ := inst.Mods(); findMod(mods, "broken") >= 0 {
+			t.Error("wanted mod with invalid file to not be recorded")
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		s.addVersion("AANobbMI", "sodium-2")
+		updates, err := UpdateMods(ctx, inst, nil, testingWatcher)
+		if err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		if len(updates) != 1 || updates[0].Old.VersionID != "sodium-1" || updates[0].New.VersionID != "sodium-2" {
+			t.Fatalf("wanted sodium to be updated; got %+v", updates)
+		}
+		if _, err := os.Stat(filepath.Join(inst.ModsDir(), "sodium-1.jar")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("wanted old file to be removed; got: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(inst.ModsDir(), "sodium-2.jar")); err != nil {
+			t.Errorf("wanted new file to be downloaded; got: %s", err)
+		}
+		if updates, _ := UpdateMods(ctx, inst, []string{"sodium"}, testingWatcher); len(updates) != 0 {
+			t.Errorf("wanted no updates; got %+v", updates)
+		}
+
+		// Fabric API no longer has a compatible version, which must not stop sodium from being updated
+		i := slices.IndexFunc(s.versions, func(version meta.ModrinthVersion) bool { return version.ID == "fabric-api-1" })
+		s.versions[i].GameVersions = []string{"1.19.4"}
+		s.addVersion("AANobbMI", "sodium-3")
+		updates, err = UpdateMods(ctx, inst, nil, nil)
+		if err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		var updated, skipped []string
+		for _, update := range updates {
+			if update.Err != nil {
+				if !errors.Is(update.Err, ErrNoCompatibleMod) {
+					t.Errorf("wanted ErrNoCompatibleMod; got %v", update.Err)
+				}
+				skipped = append(skipped, update.Old.Slug)
+				continue
+			}
+			updated = append(updated, update.New.VersionID)
+		}
+		if !slices.Equal(updated, []string{"sodium-3"}) || !slices.Equal(skipped, []string{"fabric-api"}) {
+			t.Errorf("wanted sodium to be updated and fabric-api to be skipped; got %q and %q", updated, skipped)
+		}
+		if mods, _ := inst.Mods(); findMod(mods, "fabric-api") < 0 {
+			t.Error("wanted skipped mod to be kept")
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		removed, err := RemoveMods(inst, []string{"Indium"})
+		if err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		var slugs []string
+		for _, mod := range removed {
+			slugs = append(slugs, mod.Slug)
+		}
+		// Sodium was added explicitly, so only fabric-api is removed with indium
+		if want := []string{"indium", "fabric-api"}; !slices.Equal(slugs, want) {
+			t.Errorf("wanted removed mods %q; got %q", want, slugs)
+		}
+		for _, mod := range removed {
+			if _, err := os.Stat(filepath.Join(inst.ModsDir(), mod.File)); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("wanted file of %s to be removed; got: %v", mod.Slug, err)
+			}
+		}
+		mods, _ := inst.Mods()
+		if len(mods) != 1 || mods[0].Slug != "sodium" {
+			t.Errorf("wanted only sodium to remain; got %+v", mods)
+		}
+		if _, err := RemoveMods(inst, []string{"indium"}); err == nil {
+			t.Error("wanted error removing mod which is not installed; got nil")
+		}
+	})
+}
+
+func TestSearchMods(t *testing.T) {
+	env.SetDirs(t.TempDir())
+	s := newModrinthStandIn(t)
+	s.addProject("AANobbMI", "sodium")
+
+	hits, err := SearchMods(context.Background(), newModdedInstance(t, meta.LoaderQuilt), "sod", 5)
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if len(hits) != 1 || hits[0].Slug != "sodium" {
+		t.Errorf("wanted sodium to be found; got %+v", hits)
+	}
+	want := `[["project_type:mod"],["versions:1.20.1"],["categories:quilt","categories:fabric"]]`
+	if got := s.query.Get("facets"); got != want {
+		t.Errorf("wanted facets %s; got %s", want, got)
+	}
+	if s.query.Get("query") != "sod" || s.query.Get("limit") != "5" {
+		t.Errorf("wanted query and limit to be sent; got %v", s.query)
+	}
+}
+
+// writeZip writes a ZIP file with the specified files to path.
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("unexpected error creating file for test: %s", err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("unexpected error writing ZIP for test: %s", err)
+		}
+		fw.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error writing ZIP for test: %s", err)
+	}
+}
+
+func TestImportMrpack(t *testing.T) {
+	dir := t.TempDir()
+	env.SetDirs(dir)
+	newStandIn(t)
+	s := newModrinthStandIn(t)
+	s.addProject("AANobbMI", "sodium")
+	s.addProject("P7dR8mSH", "server-only")
+	s.addVersion("AANobbMI", "sodium-1")
+	s.addVersion("P7dR8mSH", "server-only-1")
+	other := newModdedInstance(t, meta.LoaderVanilla)
+
+	mrpack := func(files []MrpackFile, overrides map[string]string) string {
+		t.Helper()
+		index, _ := json.Marshal(MrpackIndex{
+			FormatVersion: 1,
+			Game:          "minecraft",
+			Name:          "Test Pack",
+			Files:         files,
+			Dependencies:  map[string]string{"minecraft": "1.0-test"},
+		})
+		overrides[MrpackIndexName] = string(index)
+		path := filepath.Join(dir, uuid.NewString()+".mrpack")
+		writeZip(t, path, overrides)
+		return path
+	}
+	file := func(path, id string, env *MrpackEnv) MrpackFile {
+		i := slices.IndexFunc(s.versions, func(version meta.ModrinthVersion) bool { return version.ID == id })
+		return MrpackFile{
+			Path:   path,
+			Hashes: MrpackHashes{Sha1: sha1Hex([]byte(id))},
+			Env:    env,
+			// The first host doesn't have the file, so the alternate URL has to be used
+			Downloads: []string{"https://cdn.modrinth.com/data/missing.jar", s.versions[i].Files[0].URL},
+			FileSize:  int64(len(id)),
+		}
+	}
+
+	path := mrpack([]MrpackFile{
+		file("mods/sodium.jar", "sodium-1", &MrpackEnv{Client: "required", Server: "required"}),
+		file("mods/server-only.jar", "server-only-1", &MrpackEnv{Client: "unsupported", Server: "required"}),
+	}, map[string]string{
+		"overrides/config/sodium.json":   "{}",
+		"overrides/options.txt":          "fov:70",
+		"client-overrides/options.txt":   "fov:90",
+		"server-overrides/server.txt":    "server",
+		"overrides/instance.toml":        "replaced",
+		"overrides/mods/bundled-mod.jar": "bundled",
+	})
+	inst, err := ImportMrpack(context.Background(), path, InstanceOptions{}, testingWatcher)
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if inst.Name != "Test Pack" || inst.GameVersion != "1.0-test" || inst.Loader != meta.LoaderVanilla {
+		t.Errorf("wanted instance from modpack index; got %+v", inst)
+	}
+	want := map[string]string{
+		"mods/sodium.jar":      "sodium-1",
+		"config/sodium.json":   "{}",
+		"options.txt":          "fov:90",
+		"mods/bundled-mod.jar": "bundled",
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(inst.Dir(), name))
+		if err != nil || string(data) != content {
+			t.Errorf("wanted %s to contain %q; got %q, %v", name, content, data, err)
+		}
+	}
+	for _, name := range []string{"mods/server-only.jar", "server.txt"} {
+		if _, err := os.Stat(filepath.Join(inst.Dir(), name)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("wanted %s to be skipped; got: %v", name, err)
+		}
+	}
+	if _, err := FetchInstance(inst.Name); err != nil {
+		t.Errorf("wanted instance configuration to be kept; got: %s", err)
+	}
+	mods, err := inst.Mods()
+	if err != nil || len(mods) != 1 || mods[0].Slug != "sodium" || mods[0].VersionID != "sodium-1" || mods[0].File != "sodium.jar" {
+		t.Errorf("wanted sodium to be recorded in the lockfile; got %+v, %v", mods, err)
+	}
+	if index, _, err := ExportMrpack(io.Discard, inst, MrpackExportOptions{}); err != nil || len(index.Files) != 1 || index.Files[0].Path != "mods/sodium.jar" {
+		t.Errorf("wanted imported mod to be exported by URL; got %+v, %v", index.Files, err)
+	}
+
+	t.Run("LookupFailed", func(t *testing.T) {
+		s.down = true
+		t.Cleanup(func() { s.down = false })
+		path := mrpack([]MrpackFile{file("mods/sodium.jar", "sodium-1", nil)}, map[string]string{})
+		var notRecorded []error
+		inst, err := ImportMrpack(context.Background(), path, InstanceOptions{Name: "Unrecorded"}, func(event any) {
+			if e, ok := event.(ModpackModsNotRecordedEvent); ok {
+				notRecorded = append(notRecorded, e.Err)
+			}
+		})
+		if err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		if data, err := os.ReadFile(filepath.Join(inst.ModsDir(), "sodium.jar")); err != nil || string(data) != "sodium-1" {
+			t.Errorf("wanted sodium.jar to be downloaded; got %q, %v", data, err)
+		}
+		if len(notRecorded) != 1 {
+			t.Errorf("wanted one ModpackModsNotRecordedEvent; got %v", notRecorded)
+		}
+		if mods, err := inst.Mods(); err != nil || len(mods) != 0 {
+			t.Errorf("wanted no mods to be recorded; got %+v, %v", mods, err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			files []MrpackFile
+		}{
+			{"Escape", []MrpackFile{file("../escaped.jar", "sodium-1", nil)}},
+			{"Checksum", []MrpackFile{{Path: "mods/sodium.jar", Downloads: []string{"https://cdn.modrinth.com/data/AANobbMI/versions/sodium-1/sodium-1.jar"}, Hashes: MrpackHashes{Sha1: sha1Hex([]byte("other"))}}}},
+			{"NoHash", []MrpackFile{{Path: "mods/sodium.jar", Downloads: []string{"https://cdn.modrinth.com/data/AANobbMI/versions/sodium-1/sodium-1.jar"}}}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				name := uuid.NewString()
+				_, err := ImportMrpack(context.Background(), mrpack(tt.files, map[string]string{}), InstanceOptions{Name: name}, testingWatcher)
+				if err == nil {
+					t.Fatal("wanted error; got nil")
+				}
+				if DoesInstanceExist(name) {
+					t.Error("wanted instance to be removed after failed import")
+				}
+				if !DoesInstanceExist(other.Name) {
+					t.Error("wanted other instances to be kept after failed import")
+				}
+			})
+		}
+		if _, err := os.Stat(filepath.Join(env.InstancesDir, "escaped.jar")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("wanted no file outside of the instance; got: %v", err)
+		}
+	})
+}
+
+func TestMrpackIndex_Versions(t *testing.T) {
+	tests := []struct {
+		dependencies  map[string]string
+		loader        meta.Loader
+		loaderVersion string
+		wantErr       bool
+	}{
+		{map[string]string{"minecraft": "1.20.1"}, meta.LoaderVanilla, "", false},
+		{map[string]string{"minecraft": "1.20.1", "fabric-loader": "0.15.0"}, meta.LoaderFabric, "0.15.0", false},
+		{map[string]string{"minecraft": "1.20.1", "quilt-loader": "0.20.0"}, meta.LoaderQuilt, "0.20.0", false},
+		{map[string]string{"minecraft": "1.20.1", "forge": "47.2.0"}, meta.LoaderForge, "1.20.1-47.2.0", false},
+		{map[string]string{"minecraft": "1.20.4", "neoforge": "20.4.80"}, meta.LoaderNeoForge, "20.4.80", false},
+		{map[string]string{"fabric-loader": "0.15.0"}, "", "", true},
+		{map[string]string{"minecraft": "1.20.1", "fabric-loader": "0.15.0", "forge": "47.2.0"}, "", "", true},
+		{map[string]string{"minecraft": "1.20.1", "liteloader": "1.0"}, "", "", true},
+	}
+	for _, tt := range tests {
+		_, loader, loaderVersion, err := MrpackIndex{Dependencies: tt.dependencies}.versions()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("wanted error %t for %v; got: %v", tt.wantErr, tt.dependencies, err)
+			continue
+		}
+		if loader != tt.loader || loaderVersion != tt.loaderVersion {
+			t.Errorf("wanted %s %s for %v; got %s %s", tt.loader, tt.loaderVersion, tt.dependencies, loader, loaderVersion)
+		}
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"options.txt", "options.txt", true},
+		{"config", "config/sodium.json", true},
+		{"config/", "config/sodium/options.json", true},
+		{"config/*.json", "config/sodium.json", true},
+		{"config/*.json", "config/sodium/options.json", false},
+		{"config/**/*.json", "config/sodium/options.json", true},
+		{"config/**/*.json", "config/sodium.json", true},
+		{"**/*.log", "logs/launcher.log", true},
+		{"*.log", "logs/launcher.log", false},
+		{"mods", "modsettings.txt", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("wanted %t for %q matching %q; got %t", tt.want, tt.name, tt.pattern, got)
+		}
+	}
+}
+
+func TestExportMrpack(t *testing.T) {
+	env.SetDirs(t.TempDir())
+	s := newModrinthStandIn(t)
+	s.addProject("AANobbMI", "sodium")
+	s.addVersion("AANobbMI", "sodium-1")
+	inst := newModdedInstance(t, meta.LoaderFabric)
+	inst.LoaderVersion = "0.15.0"
+	if _, err := AddMods(context.Background(), inst, []string{"sodium"}, testingWatcher); err != nil {
+		t.Fatalf("unexpected error adding mod for test: %s", err)
+	}
+	for name, content := range map[string]string{
+		"options.txt":                "fov:90",
+		"config/sodium.json":         "{}",
+		"mods/custom.jar":            "custom",
+		"logs/launcher.log":          "log",
+		"saves/World/level.dat":      "world",
+		"natives/liblwjgl.so":        "native",
+		"resourcepacks/Faithful.zip": "pack",
+	} {
+		path := filepath.Join(inst.Dir(), filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error writing file for test: %s", err)
+		}
+	}
+
+	export := func(t *testing.T, options MrpackExportOptions) (MrpackIndex, []string) {
+		t.Helper()
+		var buf bytes.Buffer
+		index, overrides, err := ExportMrpack(&buf, inst, options)
+		if err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("wanted valid ZIP; got: %s", err)
+		}
+		written, err := readMrpackIndex(r)
+		if err != nil {
+			t.Fatalf("wanted valid modpack index; got: %s", err)
+		}
+		var packed []string
+		for _, f := range r.File {
+			if name, ok := strings.CutPrefix(f.Name, "overrides/"); ok {
+				packed = append(packed, name)
+			}
+		}
+		slices.Sort(packed)
+		if !slices.Equal(packed, overrides) || len(written.Files) != len(index.Files) {
+			t.Errorf("wanted written modpack to match returned index and overrides; got %q and %+v", packed, written)
+		}
+		return written, packed
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		index, overrides := export(t, MrpackExportOptions{Summary: "Test", Exclude: DefaultMrpackExclude})
+		if index.Name != inst.Name || index.VersionID != "1.0.0" || index.Summary != "Test" {
+			t.Errorf("wanted modpack name and version to default to instance; got %+v", index)
+		}
+		want := map[string]string{"minecraft": "1.20.1", "fabric-loader": "0.15.0"}
+		if !maps.Equal(index.Dependencies, want) {
+			t.Errorf("wanted dependencies %v; got %v", want, index.Dependencies)
+		}
+		// Forge versions of modpacks don't include the game version
+		forge := mrpackDependencies(Instance{GameVersion: "1.20.1", Loader: meta.LoaderForge, LoaderVersion: "1.20.1-47.2.0"})
+		if want := map[string]string{"minecraft": "1.20.1", "forge": "47.2.0"}; !maps.Equal(forge, want) {
+			t.Errorf("wanted dependencies %v; got %v", want, forge)
+		}
+		hashes, _, _ := fileHashes(filepath.Join(inst.ModsDir(), "sodium-1.jar"))
+		if len(index.Files) != 1 || index.Files[0].Path != "mods/sodium-1.jar" || index.Files[0].Hashes != hashes ||
+			!slices.Equal(index.Files[0].Downloads, []string{"https://cdn.modrinth.com/data/AANobbMI/versions/sodium-1/sodium-1.jar"}) {
+			t.Errorf("wanted sodium to be referenced by URL and hashes; got %+v", index.Files)
+		}
+		wantOverrides := []string{"config/sodium.json", "mods/custom.jar", "options.txt", "resourcepacks/Faithful.zip"}
+		if !slices.Equal(overrides, wantOverrides) {
+			t.Errorf("wanted overrides %q; got %q", wantOverrides, overrides)
+		}
+	})
+	t.Run("Include", func(t *testing.T) {
+		index, overrides := export(t, MrpackExportOptions{Include: []string{"config", "mods/*.jar"}, Exclude: []string{"mods/custom.jar"}})
+		if len(index.Files) != 1 || !slices.Equal(overrides, []string{"config/sodium.json"}) {
+			t.Errorf("wanted only included files; got %+v and %q", index.Files, overrides)
+		}
+	})
+	t.Run("Changed", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(inst.ModsDir(), "sodium-1.jar"), []byte("patched"), 0644); err != nil {
+			t.Fatalf("unexpected error writing file for test: %s", err)
+		}
+		index, overrides := export(t, MrpackExportOptions{Include: []string{"mods"}})
+		if len(index.Files) != 0 || !slices.Contains(overrides, "mods/sodium-1.jar") {
+			t.Errorf("wanted changed mod to be packed as override; got %+v and %q", index.Files, overrides)
+		}
+	})
+}
+
+func writeMultiMCInstance(t *testing.T, dir, cfg, pack string, files map[string]string) {
+	t.Helper()
+	files["instance.cfg"] = cfg
+	files["mmc-pack.json"] = pack
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMultiMCVersions(t *testing.T) {
+	tests := []struct {
+		uid, version  string
+		loader        meta.Loader
+		loaderVersion string
+	}{
+		{"net.fabricmc.fabric-loader", "0.15.0", meta.LoaderFabric, "0.15.0"},
+		{"org.quiltmc.quilt-loader", "0.23.0", meta.LoaderQuilt, "0.23.0"},
+		{"net.minecraftforge", "47.2.0", meta.LoaderForge, "1.20.1-47.2.0"},
+		{"net.neoforged", "47.1.79", meta.LoaderNeoForge, "47.1.79"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uid, func(t *testing.T) {
+			gameVersion, loader, loaderVersion, unsupported := multiMCVersions([]MultiMCComponent{
+				{UID: "org.lwjgl3", Version: "3.3.1"},
+				{UID: "net.minecraft", Version: "1.20.1"},
+				{UID: "net.fabricmc.intermediary", Version: "1.20.1"},
+				{UID: tt.uid, Version: tt.version},
+			})
+			if gameVersion != "1.20.1" || loader != tt.loader || loaderVersion != tt.loaderVersion {
+				t.Errorf("wanted 1.20.1 %s %s; got %s %s %s", tt.loader, tt.loaderVersion, gameVersion, loader, loaderVersion)
+			}
+			if len(unsupported) != 0 {
+				t.Errorf("wanted no unsupported components; got %v", unsupported)
+			}
+		})
+	}
+
+	_, loader, _, unsupported := multiMCVersions([]MultiMCComponent{
+		{UID: "net.minecraft", Version: "1.20.1"},
+		{UID: "net.fabricmc.fabric-loader", Version: "0.15.0"},
+		{UID: "net.neoforged", Version: "47.1.79"},
+		{UID: "net.optifine", Version: "1.20.1_HD_U_I6"},
+	})
+	if loader != meta.LoaderFabric || len(unsupported) != 2 {
+		t.Errorf("wanted first loader and two unsupported components; got %s and %v", loader, unsupported)
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"nice -n 5", []string{"nice", "-n", "5"}, false},
+		{`"/opt/my tools/wrap" --flag`, []string{"/opt/my tools/wrap", "--flag"}, false},
+		{`'/opt/my tools/wrap' --name="a b"`, []string{"/opt/my tools/wrap", "--name=a b"}, false},
+		{`/opt/my\ tools/wrap "say \"hi\""`, []string{"/opt/my tools/wrap", `say "hi"`}, false},
+		{`C:\Tools\wrap.exe ""`, []string{`C:\Tools\wrap.exe`, ""}, false},
+		{`"/opt/my tools/wrap --flag`, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := splitCommand(tt.command)
+		if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+			t.Errorf("%s: wanted %q (error: %t); got %q, %v", tt.command, tt.want, tt.wantErr, got, err)
+		}
+	}
+}
+
+func TestImportMultiMC(t *testing.T) {
+	dir := t.TempDir()
+	env.SetDirs(dir)
+	newStandIn(t)
+	other := newModdedInstance(t, meta.LoaderVanilla)
+
+	cfg := `[General]
+InstanceType=OneSix
+name=Test Instance
+OverrideMemory=true
+MinMemAlloc=1024
+MaxMemAlloc=6144
+OverrideJavaLocation=true
+JavaPath=/usr/lib/jvm/java-17/bin/java
+OverrideJavaArgs=true
+JvmArgs="-XX:+UseG1GC -Dfoo=bar"
+OverrideWindow=true
+MinecraftWinWidth=1280
+MinecraftWinHeight=720
+OverrideCommands=false
+WrapperCommand=gamemoderun
+`
+	pack := `{"components": [
+	{"uid": "org.lwjgl3", "version": "3.3.1", "dependencyOnly": true},
+	{"uid": "net.minecraft", "version": "1.0-test", "important": true},
+	{"uid": "com.mumfrey.liteloader", "version": "1.12.2"}
+], "formatVersion": 1}`
+
+	src := filepath.Join(t.TempDir(), "prism")
+	writeMultiMCInstance(t, src, cfg, pack, map[string]string{
+		".minecraft/options.txt":       "fov:90",
+		".minecraft/mods/sodium.jar":   "sodium",
+		".minecraft/saves/world/level": "level",
+		".minecraft/instance.toml":     "replaced",
+		"patches/net.minecraft.json":   "{}",
+		"jarmods/custom.jar":           "jar",
+	})
+
+	inst, unsupported, err := ImportMultiMC(context.Background(), src, MultiMCImportOptions{
+		InstanceOptions: InstanceOptions{Config: InstanceConfig{MinMemory: 512, MaxMemory: 4096}},
+	})
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if inst.Name != "Test Instance" || inst.GameVersion != "1.0-test" || inst.Loader != meta.LoaderVanilla {
+		t.Errorf("wanted instance from mmc-pack.json; got %+v", inst)
+	}
+	config := inst.Config
+	if config.MinMemory != 1024 || config.MaxMemory != 6144 || config.Java != "/usr/lib/jvm/java-17/bin/java" ||
+		config.JavaArgs != "-XX:+UseG1GC -Dfoo=bar" || config.WindowResolution.Width != 1280 || config.WindowResolution.Height != 720 {
+		t.Errorf("wanted overridden settings to be carried over; got %+v", config)
+	}
+	if len(config.Wrapper) != 0 {
+		t.Errorf("wanted commands which are not overridden to be ignored; got %q", config.Wrapper)
+	}
+	var components []string
+	for _, err := range unsupported {
+		var e *UnsupportedComponentError
+		if errors.As(err, &e) {
+			components = append(components, e.Component)
+		}
+	}
+	slices.Sort(components)
+	if want := []string{"com.mumfrey.liteloader", "jarmods/custom.jar", "patches/net.minecraft.json"}; !slices.Equal(components, want) {
+		t.Errorf("wanted unsupported components %q; got %q", want, components)
+	}
+	for name, content := range map[string]string{"options.txt": "fov:90", "mods/sodium.jar": "sodium", "saves/world/level": "level"} {
+		data, err := os.ReadFile(filepath.Join(inst.Dir(), name))
+		if err != nil || string(data) != content {
+			t.Errorf("wanted %s to contain %q; got %q, %v", name, content, data, err)
+		}
+	}
+	if _, err := FetchInstance(inst.Name); err != nil {
+		t.Errorf("wanted instance configuration to be kept; got: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(src, ".minecraft", "options.txt")); err != nil {
+		t.Errorf("wanted game directory to be copied; got: %s", err)
+	}
+
+	t.Run("Move", func(t *testing.T) {
+		src := filepath.Join(t.TempDir(), "multimc")
+		writeMultiMCInstance(t, src, "name=Old\nOverrideCommands=true\nWrapperCommand=\"\\\"/opt/my tools/nice\\\" -n 5\"\n", pack, map[string]string{
+			"minecraft/options.txt": "fov:70",
+		})
+		inst, _, err := ImportMultiMC(context.Background(), src, MultiMCImportOptions{
+			InstanceOptions: InstanceOptions{Name: "Moved"},
+			Move:            true,
+		})
+		if err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		if inst.Name != "Moved" || !slices.Equal(inst.Config.Wrapper, []string{"/opt/my tools/nice", "-n", "5"}) {
+			t.Errorf("wanted name option and wrapper command; got %+v", inst)
+		}
+		if data, err := os.ReadFile(filepath.Join(inst.Dir(), "options.txt")); err != nil || string(data) != "fov:70" {
+			t.Errorf("wanted options.txt to be moved; got %q, %v", data, err)
+		}
+		if _, err := os.Stat(filepath.Join(src, "minecraft", "options.txt")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("wanted game directory to be moved; got: %v", err)
+		}
+	})
+
+	t.Run("InvalidWrapper", func(t *testing.T) {
+		src := filepath.Join(t.TempDir(), "wrapper")
+		writeMultiMCInstance(t, src, "name=Wrapper\nOverrideCommands=true\nWrapperCommand='/opt/my tools/nice\n", pack, map[string]string{})
+		inst, unsupported, err := ImportMultiMC(context.Background(), src, MultiMCImportOptions{})
+		if err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		if len(inst.Config.Wrapper) != 0 {
+			t.Errorf("wanted no wrapper command; got %q", inst.Config.Wrapper)
+		}
+		if !slices.ContainsFunc(unsupported, func(err error) bool {
+			var e *UnsupportedComponentError
+			return errors.As(err, &e) && e.Component == "WrapperCommand"
+		}) {
+			t.Errorf("wanted wrapper command to be unsupported; got %v", unsupported)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		src := filepath.Join(t.TempDir(), "invalid")
+		writeMultiMCInstance(t, src, "name=Invalid\n", `{"components": [{"uid": "net.minecraft", "version": "0.0-missing"}]}`, map[string]string{})
+		if _, _, err := ImportMultiMC(context.Background(), src, MultiMCImportOptions{}); err == nil {
+			t.Fatal("wanted error; got nil")
+		}
+		if DoesInstanceExist("Invalid") {
+			t.Error("wanted no instance after failed import")
+		}
+		if !DoesInstanceExist(other.Name) {
+			t.Error("wanted other instances to be kept after failed import")
+		}
+	})
+}
+
+func TestCurseForgeManifest_Versions(t *testing.T) {
+	tests := []struct {
+		loaders       []string
+		loader        meta.Loader
+		loaderVersion string
+	}{
+		{nil, meta.LoaderVanilla, ""},
+		{[]string{"fabric-0.15.0"}, meta.LoaderFabric, "0.15.0"},
+		{[]string{"quilt-0.23.0"}, meta.LoaderQuilt, "0.23.0"},
+		{[]string{"forge-47.2.0"}, meta.LoaderForge, "1.20.1-47.2.0"},
+		{[]string{"neoforge-47.1.79"}, meta.LoaderNeoForge, "47.1.79"},
+	}
+	for _, tt := range tests {
+		var manifest CurseForgeManifest
+		manifest.Minecraft.Version = "1.20.1"
+		for _, id := range tt.loaders {
+			manifest.Minecraft.ModLoaders = append(manifest.Minecraft.ModLoaders, struct {
+				ID      string `json:"id"`
+				Primary bool   `json:"primary"`
+			}{id, true})
+		}
+		_, loader, loaderVersion, err := manifest.versions()
+		if err != nil || loader != tt.loader || loaderVersion != tt.loaderVersion {
+			t.Errorf("%q: wanted %s %s; got %s %s, %v", tt.loaders, tt.loader, tt.loaderVersion, loader, loaderVersion, err)
+		}
+	}
+
+	var manifest CurseForgeManifest
+	manifest.Minecraft.Version = "1.20.1"
+	json.Unmarshal([]byte(`[{"id": "forge-47.2.0"}, {"id": "fabric-0.15.0", "primary": true}]`), &manifest.Minecraft.ModLoaders)
+	if _, loader, _, _ := manifest.versions(); loader != meta.LoaderFabric {
+		t.Errorf("wanted primary mod loader; got %s", loader)
+	}
+	json.Unmarshal([]byte(`[{"id": "liteloader-1.12.2", "primary": true}]`), &manifest.Minecraft.ModLoaders)
+	if _, _, _, err := manifest.versions(); err == nil {
+		t.Error("wanted error for unsupported mod loader; got nil")
+	}
+}
+
+// newCurseForgeStandIn starts a local stand-in for the CurseForge API and CDN, serving files of the project IDs
+// in files. Files with an empty download URL don't allow third-party downloads.
+func newCurseForgeStandIn(t *testing.T, files map[int]meta.CurseForgeFile, data map[string][]byte) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name, ok := strings.CutPrefix(r.URL.Path, "/files/"); ok {
+			if _, ok := data[name]; !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(data[name])
+			return
+		}
+		if r.Header.Get("x-api-key") != "test-key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var projectID, fileID int
+		if _, err := fmt.Sscanf(r.URL.Path, "/v1/mods/%d/files/%d", &projectID, &fileID); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		file, ok := files[projectID]
+		if !ok || file.ID != fileID {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": file})
+	}))
+	mirrors := network.Mirrors()
+	SetMirrors(append(mirrors,
+		Mirror{Upstream: "https://api.curseforge.com", Bases: []string{s.URL}, Exclusive: true},
+		Mirror{Upstream: "https://edge.forgecdn.net", Bases: []string{s.URL}, Exclusive: true},
+	)...)
+	t.Cleanup(func() {
+		SetMirrors(mirrors...)
+		s.Close()
+	})
+}
+
+func TestImportCurseForge(t *testing.T) {
+	dir := t.TempDir()
+	env.SetDirs(dir)
+	newStandIn(t)
+	other := newModdedInstance(t, meta.LoaderVanilla)
+
+	curseForgeFile := func(projectID, fileID int, name, url string) meta.CurseForgeFile {
+		file := meta.CurseForgeFile{ID: fileID, ModID: projectID, FileName: name, DownloadURL: url, FileLength: int64(len(name))}
+		file.Hashes = append(file.Hashes, struct {
+			Value string `json:"value"`
+			Algo  int    `json:"algo"`
+		}{sha1Hex([]byte(name)), meta.CurseForgeSha1})
+		return file
+	}
+	newCurseForgeStandIn(t, map[int]meta.CurseForgeFile{
+		100: curseForgeFile(100, 1, "jei.jar", "https://edge.forgecdn.net/files/jei.jar"),
+		200: curseForgeFile(200, 2, "optifine.jar", ""),
+		400: curseForgeFile(400, 4, "disabled.jar", "https://edge.forgecdn.net/files/disabled.jar"),
+		500: curseForgeFile(500, 5, "missing.jar", "https://edge.forgecdn.net/files/missing.jar"),
+	}, map[string][]byte{"jei.jar": []byte("jei.jar"), "disabled.jar": []byte("disabled.jar")})
+
+	path := filepath.Join(dir, "pack.zip")
+	writeZip(t, path, map[string]string{
+		CurseForgeManifestName: `{
+			"minecraft": {"version": "1.0-test", "modLoaders": []},
+			"manifestType": "minecraftModpack",
+			"manifestVersion": 1,
+			"name": "Curse Pack",
+			"files": [
+				{"projectID": 100, "fileID": 1, "required": true},
+				{"projectID": 200, "fileID": 2, "required": true},
+				{"projectID": 300, "fileID": 3, "required": true},
+				{"projectID": 400, "fileID": 4, "required": false}
+			],
+			"overrides": "overrides"
+		}`,
+		"overrides/config/jei.toml": "jei",
+		"overrides/instance.toml":   "replaced",
+	})
+
+	inst, unresolved, err := ImportCurseForge(context.Background(), path, InstanceOptions{}, CurseForgeAPIResolver{APIKey: "test-key"}, testingWatcher)
+	if err != nil {
+		t.Fatalf("wanted no error; got: %s", err)
+	}
+	if inst.Name != "Curse Pack" || inst.GameVersion != "1.0-test" || inst.Loader != meta.LoaderVanilla {
+		t.Errorf("wanted instance from modpack manifest; got %+v", inst)
+	}
+	for name, content := range map[string]string{"mods/jei.jar": "jei.jar", "config/jei.toml": "jei"} {
+		data, err := os.ReadFile(filepath.Join(inst.Dir(), name))
+		if err != nil || string(data) != content {
+			t.Errorf("wanted %s to contain %q; got %q, %v", name, content, data, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(inst.ModsDir(), "disabled.jar")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("wanted files which are not required to be skipped; got: %v", err)
+	}
+	if _, err := FetchInstance(inst.Name); err != nil {
+		t.Errorf("wanted instance configuration to be kept; got: %s", err)
+	}
+	var projects []int
+	for _, err := range unresolved {
+		var e *UnresolvedFileError
+		if errors.As(err, &e) {
+			projects = append(projects, e.ProjectID)
+		}
+	}
+	if !slices.Equal(projects, []int{200, 300}) || !errors.Is(unresolved[0], ErrNoThirdPartyDownload) || !errors.Is(unresolved[1], meta.ErrCurseForgeNotFound) {
+		t.Errorf("wanted projects 200 and 300 to be unresolved; got %v", unresolved)
+	}
+
+	t.Run("Directory", func(t *testing.T) {
+		mods := t.TempDir()
+		for _, name := range []string{"100/1/jei.jar", "200/2/optifine.jar"} {
+			os.MkdirAll(filepath.Join(mods, filepath.Dir(name)), 0755)
+			os.WriteFile(filepath.Join(mods, name), []byte(filepath.Base(name)), 0644)
+		}
+		inst, unresolved, err := ImportCurseForge(context.Background(), path, InstanceOptions{Name: "Local"}, CurseForgeDirResolver{Dir: mods}, testingWatcher)
+		if err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		for _, name := range []string{"jei.jar", "optifine.jar"} {
+			if data, err := os.ReadFile(filepath.Join(inst.ModsDir(), name)); err != nil || string(data) != name {
+				t.Errorf("wanted %s to be copied; got %q, %v", name, data, err)
+			}
+		}
+		var e *UnresolvedFileError
+		if len(unresolved) != 1 || !errors.As(unresolved[0], &e) || e.ProjectID != 300 {
+			t.Errorf("wanted project 300 to be unresolved; got %v", unresolved)
+		}
+	})
+
+	t.Run("DownloadFailed", func(t *testing.T) {
+		path := filepath.Join(dir, "missing.zip")
+		writeZip(t, path, map[string]string{
+			CurseForgeManifestName: `{
+				"minecraft": {"version": "1.0-test", "modLoaders": []},
+				"manifestType": "minecraftModpack",
+				"manifestVersion": 1,
+				"name": "Missing Pack",
+				"files": [
+					{"projectID": 100, "fileID": 1, "required": true},
+					{"projectID": 500, "fileID": 5, "required": true}
+				]
+			}`,
+		})
+		inst, unresolved, err := ImportCurseForge(context.Background(), path, InstanceOptions{}, CurseForgeAPIResolver{APIKey: "test-key"}, testingWatcher)
+		if err != nil {
+			t.Fatalf("wanted no error; got: %s", err)
+		}
+		if data, err := os.ReadFile(filepath.Join(inst.ModsDir(), "jei.jar")); err != nil || string(data) != "jei.jar" {
+			t.Errorf("wanted jei.jar to be downloaded; got %q, %v", data, err)
+		}
+		var e *UnresolvedFileError
+		var statusErr *network.HTTPStatusError
+		if len(unresolved) != 1 || !errors.As(unresolved[0], &e) || e.ProjectID != 500 || e.FileID != 5 ||
+			!errors.As(unresolved[0], &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+			t.Errorf("wanted project 500 to be unresolved with status 404; got %v", unresolved)
+		}
+		entries, _ := os.ReadDir(inst.ModsDir())
+		if len(entries) != 1 {
+			t.Errorf("wanted only jei.jar in the mods directory; got %d files", len(entries))
+		}
+	})
+
+	t.Run("NoKey", func(t *testing.T) {
+		_, _, err := ImportCurseForge(context.Background(), path, InstanceOptions{Name: "NoKey"}, CurseForgeAPIResolver{APIKey: "wrong"}, testingWatcher)
+		if !errors.Is(err, meta.ErrCurseForgeUnauthorized) {
+			t.Errorf("wanted ErrCurseForgeUnauthorized; got %v", err)
+		}
+		if DoesInstanceExist("NoKey") {
+			t.Error("wanted instance to be removed after failed import")
+		}
+		if !DoesInstanceExist(other.Name) {
+			t.Error("wanted other instances to be kept after failed import")
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package launcher
 
 import (
+	"context"
 	"fmt"
 	"runtime"
 
@@ -11,7 +12,7 @@ import (
 // filterLibraries sorts game libraries into installed and required libraries.
 //
 // It also runs patchLibrary on each library.
-func filterLibraries(libraries []meta.Library) (installed []meta.Library, required []meta.Library) {
+func filterLibraries(ctx context.Context, libraries []meta.Library) (installed []meta.Library, required []meta.Library) {
 	for _, library := range libraries {
 		if !library.ShouldInstall {
 			continue
@@ -21,7 +22,7 @@ func filterLibraries(libraries []meta.Library) (installed []meta.Library, requir
 			required = append(required, library)
 			continue
 		}
-		library = patchLibrary(library)
+		library = patchLibrary(ctx, library)
 		if library.Artifact.IsDownloaded() {
 			installed = append(installed, library)
 		} else {
@@ -32,7 +33,7 @@ func filterLibraries(libraries []meta.Library) (installed []meta.Library, requir
 }
 
 // patchLibrary takes library and replaces it with any applicable fixed libraries.
-func patchLibrary(library meta.Library) meta.Library {
+func patchLibrary(ctx context.Context, library meta.Library) meta.Library {
 	specifier := library.Specifier
 	if specifier.Group == "org.lwjgl" &&
 		specifier.Classifier == "natives-linux" &&
@@ -66,7 +67,7 @@ func patchLibrary(library meta.Library) meta.Library {
 		}
 
 		specifier.Classifier = "natives-linux-arm64"
-		library, err := meta.FetchMavenLibrary(specifier)
+		library, err := meta.FetchMavenLibrary(ctx, specifier)
 		if err == nil {
 			return library
 		}
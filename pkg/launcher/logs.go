@@ -0,0 +1,194 @@
+package launcher
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogEvent is called by LogRunner for every message the game outputs.
+//
+// With Mojang's log4j configuration, the game outputs its log as XML events, which are parsed into their fields.
+// Any other output, such as messages printed before log4j is initialized, only has Message set.
+type LogEvent struct {
+	Time      time.Time
+	Level     string // For example "INFO" or "ERROR", or empty for output which is not a log event
+	Thread    string
+	Logger    string
+	Message   string
+	Throwable string // Stack trace of an exception logged with the message, if any
+	Stderr    bool   // Whether the output was written to stderr
+}
+
+// String formats the event like the game formats its own log files.
+func (e LogEvent) String() string {
+	s := e.Message
+	if e.Level != "" {
+		s = fmt.Sprintf("[%s] [%s/%s]: %s", e.Time.Format(time.TimeOnly), e.Thread, e.Level, e.Message)
+	}
+	if e.Throwable != "" {
+		s += "\n" + e.Throwable
+	}
+	return s
+}
+
+// MaxLogFiles is the number of log files LogRunner keeps for each instance, including the current one.
+var MaxLogFiles = 5
+
+// LogRunner returns an implementation of Runner which captures the game's output and sends a LogEvent to watcher for each message.
+//
+// All messages are also written to "logs/launcher.log" in the game directory. Logs of previous
+// launches are kept as "launcher.1.log", "launcher.2.log" and so on, up to MaxLogFiles.
+func LogRunner(watcher EventWatcher) Runner {
+	return func(cmd *exec.Cmd) error {
+		dir := filepath.Join(cmd.Dir, "logs")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("create logs directory: %w", err)
+		}
+		path := filepath.Join(dir, "launcher.log")
+		if err := rotateLogs(path); err != nil {
+			return fmt.Errorf("rotate logs: %w", err)
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("create log file: %w", err)
+		}
+		defer file.Close()
+
+		cmd.Stdin = os.Stdin
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		stderr, err := cmd.StderrPipe()
+		if err != nil {
+			return err
+		}
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+
+		var mu sync.Mutex
+		handle := func(event LogEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			fmt.Fprintln(file, event)
+			watcher(event)
+		}
+		var wg sync.WaitGroup
+		var stdoutErr, stderrErr error
+		wg.Go(func() { stdoutErr = scanLog(stdout, false, handle) })
+		wg.Go(func() { stderrErr = scanLog(stderr, true, handle) })
+		wg.Wait()
+		if err := cmd.Wait(); err != nil {
+			return err
+		}
+		if err := errors.Join(stdoutErr, stderrErr); err != nil {
+			return fmt.Errorf("read game output: %w", err)
+		}
+		return nil
+	}
+}
+
+// rotateLogs renames the log file at path and its previous versions, so that at most MaxLogFiles-1 remain.
+func rotateLogs(path string) error {
+	ext := filepath.Ext(path)
+	name := func(i int) string {
+		if i == 0 {
+			return path
+		}
+		return strings.TrimSuffix(path, ext) + "." + strconv.Itoa(i) + ext
+	}
+	for i := MaxLogFiles - 1; i > 0; i-- {
+		err := os.Rename(name(i-1), name(i))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if MaxLogFiles <= 1 {
+		os.Remove(path)
+	}
+	return nil
+}
+
+// xmlLogEvent is a log event written by log4j's XML layout.
+type xmlLogEvent struct {
+	Logger    string `xml:"logger,attr"`
+	Timestamp int64  `xml:"timestamp,attr"` // Milliseconds since the Unix epoch
+	Level     string `xml:"level,attr"`
+	Thread    string `xml:"thread,attr"`
+	Message   string `xml:"Message"`
+	Throwable string `xml:"Throwable"`
+}
+
+// maxLogLine is the length after which a line of game output is split into several messages.
+const maxLogLine = 1024 * 1024
+
+// scanLog reads output of the game from r and calls handle with every message.
+//
+// Lines are collected into a single message from a line starting a log4j XML event to the line ending it.
+// Lines longer than maxLogLine are split. If reading fails, the rest of r is discarded, so the game never
+// blocks on a full pipe.
+func scanLog(r io.Reader, stderr bool, handle func(LogEvent)) error {
+	reader := bufio.NewReader(r)
+
+	var event strings.Builder
+	var line []byte
+	for {
+		chunk, more, err := reader.ReadLine()
+		if err != nil {
+			if event.Len() > 0 {
+				// The game exited in the middle of an event
+				handle(LogEvent{Time: time.Now(), Message: strings.TrimSuffix(event.String(), "\n"), Stderr: stderr})
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			io.Copy(io.Discard, r)
+			return err
+		}
+		line = append(line, chunk...)
+		if more && len(line) < maxLogLine {
+			continue
+		}
+		text := string(line)
+		line = line[:0]
+
+		if event.Len() == 0 && !strings.HasPrefix(strings.TrimSpace(text), "<log4j:Event") {
+			handle(LogEvent{Time: time.Now(), Message: text, Stderr: stderr})
+			continue
+		}
+		event.WriteString(text)
+		event.WriteByte('\n')
+		if strings.Contains(text, "</log4j:Event>") {
+			handle(parseLogEvent(event.String(), stderr))
+			event.Reset()
+		}
+	}
+}
+
+// parseLogEvent parses a log4j XML event. If it is invalid, it is returned as the message of the event.
+func parseLogEvent(data string, stderr bool) LogEvent {
+	var e xmlLogEvent
+	if err := xml.Unmarshal([]byte(data), &e); err != nil {
+		return LogEvent{Time: time.Now(), Message: strings.TrimSuffix(data, "\n"), Stderr: stderr}
+	}
+	return LogEvent{
+		Time:      time.UnixMilli(e.Timestamp),
+		Level:     e.Level,
+		Thread:    e.Thread,
+		Logger:    e.Logger,
+		Message:   e.Message,
+		Throwable: strings.TrimRight(e.Throwable, "\r\n"),
+		Stderr:    stderr,
+	}
+}
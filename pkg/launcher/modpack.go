@@ -0,0 +1,516 @@
+package launcher
+
+import (
+	"archive/zip"
+	"cmp"
+	"context"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/telecter/cmd-launcher/internal/meta"
+	"github.com/telecter/cmd-launcher/internal/network"
+)
+
+// MrpackIndexName is the name of the index file in a Modrinth modpack.
+const MrpackIndexName = "modrinth.index.json"
+
+// An MrpackIndex is the index of a Modrinth modpack (.mrpack), which describes its game version, mod loader and files.
+type MrpackIndex struct {
+	FormatVersion int               `json:"formatVersion"`
+	Game          string            `json:"game"`
+	VersionID     string            `json:"versionId"`
+	Name          string            `json:"name"`
+	Summary       string            `json:"summary,omitempty"`
+	Files         []MrpackFile      `json:"files"`
+	Dependencies  map[string]string `json:"dependencies"` // Game and mod loader versions, such as "minecraft" and "fabric-loader"
+}
+
+// An MrpackFile is a file of a Modrinth modpack which is downloaded when it is installed.
+type MrpackFile struct {
+	Path      string       `json:"path"` // Destination relative to the instance directory
+	Hashes    MrpackHashes `json:"hashes"`
+	Env       *MrpackEnv   `json:"env,omitempty"`
+	Downloads []string     `json:"downloads"`
+	FileSize  int64        `json:"fileSize"`
+}
+
+// MrpackHashes are the hashes of a file in a Modrinth modpack.
+type MrpackHashes struct {
+	Sha1   string `json:"sha1"`
+	Sha512 string `json:"sha512"`
+}
+
+// MrpackEnv describes whether a file of a Modrinth modpack is "required", "optional" or "unsupported" on clients and servers.
+type MrpackEnv struct {
+	Client string `json:"client"`
+	Server string `json:"server"`
+}
+
+// mrpackLoaders maps the dependency names of mod loaders in Modrinth modpacks to loaders.
+var mrpackLoaders = map[string]meta.Loader{
+	"fabric-loader": meta.LoaderFabric,
+	"quilt-loader":  meta.LoaderQuilt,
+	"forge":         meta.LoaderForge,
+	"neoforge":      meta.LoaderNeoForge,
+}
+
+// versions returns the game version, loader and loader version the modpack depends on.
+func (index MrpackIndex) versions() (gameVersion string, loader meta.Loader, loaderVersion string, err error) {
+	gameVersion = index.Dependencies["minecraft"]
+	if gameVersion == "" {
+		return "", "", "", fmt.Errorf("modpack does not specify a game version")
+	}
+	loader = meta.LoaderVanilla
+	for name, version := range index.Dependencies {
+		if name == "minecraft" {
+			continue
+		}
+		l, ok := mrpackLoaders[name]
+		if !ok {
+			return "", "", "", fmt.Errorf("unsupported modpack dependency %q", name)
+		}
+		if loader != meta.LoaderVanilla {
+			return "", "", "", fmt.Errorf("modpack depends on more than one mod loader")
+		}
+		loader, loaderVersion = l, version
+	}
+	// Forge versions of modpacks don't include the game version
+	if loader == meta.LoaderForge && !strings.HasPrefix(loaderVersion, gameVersion+"-") {
+		loaderVersion = gameVersion + "-" + loaderVersion
+	}
+	return gameVersion, loader, loaderVersion, nil
+}
+
+// readMrpackIndex reads the index of the Modrinth modpack r.
+func readMrpackIndex(r *zip.Reader) (MrpackIndex, error) {
+	f, err := r.Open(MrpackIndexName)
+	if err != nil {
+		return MrpackIndex{}, fmt.Errorf("open modpack index: %w", err)
+	}
+	defer f.Close()
+	var index MrpackIndex
+	if err := json.NewDecoder(f).Decode(&index); err != nil {
+		return MrpackIndex{}, fmt.Errorf("parse modpack index: %w", err)
+	}
+	if index.FormatVersion != 1 {
+		return MrpackIndex{}, fmt.Errorf("unsupported modpack format version %d", index.FormatVersion)
+	}
+	if index.Game != "minecraft" {
+		return MrpackIndex{}, fmt.Errorf("unsupported modpack game %q", index.Game)
+	}
+	return index, nil
+}
+
+// joinWithin joins dir and the slash-separated relative path rel, returning an error if the result is outside of dir.
+func joinWithin(dir, rel string) (string, error) {
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	if filepath.IsAbs(filepath.FromSlash(rel)) || strings.Contains(rel, `\`) || !isWithin(dir, path) || path == filepath.Clean(dir) {
+		return "", fmt.Errorf("invalid path %q", rel)
+	}
+	return path, nil
+}
+
+// extractZipFile writes the contents of f to path, creating any parent directories.
+func extractZipFile(f *zip.File, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// ImportMrpack creates an instance from the Modrinth modpack (.mrpack) at path.
+//
+// The game version and mod loader of the instance are taken from the modpack, so only the name and configuration
+// of options are used. If the name is empty, the name of the modpack is used. All files of the modpack which are
+// not unsupported on clients are downloaded and verified with their SHA-1 checksum, trying their alternate URLs if
+// needed, and the overrides of the modpack are copied into the instance directory. Mods hosted on Modrinth are
+// recorded in the mod lockfile, as if they were installed with AddMods. If they can't be looked up on Modrinth, a
+// ModpackModsNotRecordedEvent is sent instead. If anything else fails, the instance is removed again.
+func ImportMrpack(ctx context.Context, path string, options InstanceOptions, watcher EventWatcher) (inst Instance, err error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return Instance{}, fmt.Errorf("open modpack: %w", err)
+	}
+	defer r.Close()
+	index, err := readMrpackIndex(&r.Reader)
+	if err != nil {
+		return Instance{}, err
+	}
+
+	options.GameVersion, options.Loader, options.LoaderVersion, err = index.versions()
+	if err != nil {
+		return Instance{}, err
+	}
+	if options.Name == "" {
+		options.Name = index.Name
+	}
+	if filepath.Base(options.Name) != options.Name {
+		return Instance{}, fmt.Errorf("invalid instance name")
+	}
+	inst, err = CreateInstanceContext(ctx, options)
+	if err != nil {
+		return Instance{}, fmt.Errorf("create instance: %w", err)
+	}
+	// Captured, as inst is zero when an error is returned
+	dir := inst.Dir()
+	defer func() {
+		if err != nil {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	var entries []network.DownloadEntry
+	var files []MrpackFile
+	for _, file := range index.Files {
+		if file.Env != nil && file.Env.Client == "unsupported" {
+			continue
+		}
+		dest, err := joinWithin(inst.Dir(), file.Path)
+		if err != nil {
+			return Instance{}, err
+		}
+		if len(file.Downloads) == 0 {
+			return Instance{}, fmt.Errorf("no download for file %q", file.Path)
+		}
+		// Files are only downloaded if they can be verified
+		if file.Hashes.Sha1 == "" {
+			return Instance{}, fmt.Errorf("no SHA-1 hash for file %q", file.Path)
+		}
+		// The other URLs are alternates, used if the first host is unavailable
+		entries = append(entries, network.DownloadEntry{
+			URL:       file.Downloads[0],
+			Fallbacks: file.Downloads[1:],
+			Path:      dest,
+			Sha1:      file.Hashes.Sha1,
+			Size:      file.FileSize,
+		})
+		files = append(files, file)
+	}
+	if err := download(ctx, entries, nil, watcher); err != nil {
+		return Instance{}, fmt.Errorf("download modpack files: %w", err)
+	}
+
+	// Client overrides are applied last, so they replace common overrides
+	for _, prefix := range []string{"overrides/", "client-overrides/"} {
+		for _, f := range r.File {
+			name, ok := strings.CutPrefix(f.Name, prefix)
+			// The instance configuration is never replaced
+			if !ok || name == "" || f.FileInfo().IsDir() || name == "instance.toml" || name == "instance.json" {
+				continue
+			}
+			dest, err := joinWithin(inst.Dir(), name)
+			if err != nil {
+				return Instance{}, err
+			}
+			if err := extractZipFile(f, dest); err != nil {
+				return Instance{}, fmt.Errorf("extract %q: %w", f.Name, err)
+			}
+		}
+	}
+
+	// The instance works without the lockfile, its mods just can't be updated
+	mods, lookupErr := mrpackMods(ctx, files)
+	if lookupErr != nil {
+		watcher(ModpackModsNotRecordedEvent{Err: lookupErr})
+	} else if len(mods) > 0 {
+		if err := writeMods(inst, mods); err != nil {
+			return Instance{}, err
+		}
+	}
+	return inst, nil
+}
+
+// ModpackModsNotRecordedEvent is called when the mods of an imported modpack can't be looked up on Modrinth, so
+// they are not recorded in the mod lockfile.
+type ModpackModsNotRecordedEvent struct {
+	Err error
+}
+
+// modrinthFilePattern matches download URLs of files hosted on Modrinth, capturing their project and version ID.
+var modrinthFilePattern = regexp.MustCompile(`^https://cdn\.modrinth\.com/data/([^/]+)/versions/([^/]+)/[^/]+$`)
+
+// mrpackMods returns the files of a Modrinth modpack in the mods directory which are hosted on Modrinth as mods,
+// so they can be updated and exported like mods installed with AddMods.
+func mrpackMods(ctx context.Context, files []MrpackFile) ([]Mod, error) {
+	byVersion := make(map[string]Mod)
+	for _, file := range files {
+		name, ok := strings.CutPrefix(file.Path, "mods/")
+		if !ok || strings.Contains(name, "/") {
+			continue
+		}
+		for _, u := range file.Downloads {
+			if m := modrinthFilePattern.FindStringSubmatch(u); m != nil {
+				byVersion[m[2]] = Mod{
+					ProjectID: m[1],
+					VersionID: m[2],
+					File:      name,
+					URL:       u,
+					Sha1:      file.Hashes.Sha1,
+					Sha512:    file.Hashes.Sha512,
+					Size:      file.FileSize,
+				}
+				break
+			}
+		}
+	}
+	if len(byVersion) == 0 {
+		return nil, nil
+	}
+
+	versions, err := meta.FetchModrinthVersionsByID(ctx, slices.Sorted(maps.Keys(byVersion)))
+	if err != nil {
+		return nil, err
+	}
+	var projectIDs []string
+	for _, version := range versions {
+		projectIDs = append(projectIDs, version.ProjectID)
+	}
+	projects, err := meta.FetchModrinthProjects(ctx, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	versionProjects := make(map[string]string)
+	for _, version := range versions {
+		versionProjects[version.ID] = version.ProjectID
+	}
+
+	var mods []Mod
+	for _, version := range versions {
+		mod, ok := byVersion[version.ID]
+		i := slices.IndexFunc(projects, func(project meta.ModrinthProject) bool { return project.ID == version.ProjectID })
+		if !ok || i < 0 {
+			continue
+		}
+		mod.ProjectID, mod.Slug, mod.Title, mod.Version = projects[i].ID, projects[i].Slug, projects[i].Title, version.VersionNumber
+		// Only dependencies which are part of the modpack are recorded
+		for _, dep := range version.Dependencies {
+			id := cmp.Or(dep.ProjectID, versionProjects[dep.VersionID])
+			if dep.DependencyType == "required" && slices.Contains(projectIDs, id) && !slices.Contains(mod.Dependencies, id) {
+				mod.Dependencies = append(mod.Dependencies, id)
+			}
+		}
+		mods = append(mods, mod)
+	}
+	return mods, nil
+}
+
+// MrpackExportOptions configures how an instance is exported as a Modrinth modpack.
+type MrpackExportOptions struct {
+	Name    string // Name of the modpack. Defaults to the name of the instance.
+	Version string // Version of the modpack. Defaults to "1.0.0".
+	Summary string
+
+	// Include contains glob patterns of files to include, relative to the instance directory. If empty, all files
+	// are included. Patterns use "/" as separator, "**" matches any number of directories, and a pattern which
+	// matches a directory matches all files in it.
+	Include []string
+	// Exclude contains glob patterns of files to leave out, in the same format as Include.
+	Exclude []string
+}
+
+// DefaultMrpackExclude contains patterns of files in an instance which usually don't belong in a modpack.
+var DefaultMrpackExclude = []string{"logs", "crash-reports", "saves", "screenshots", "resources", "hs_err_pid*.log"}
+
+// mrpackLauncherFiles are files of the launcher which are never exported.
+var mrpackLauncherFiles = []string{"instance.toml", "instance.json", "history.json", "mods.lock.json", "natives"}
+
+// matchGlob reports whether the slash-separated path name, or any directory containing it, matches pattern.
+func matchGlob(pattern, name string) bool {
+	var match func(pattern, name []string) bool
+	match = func(pattern, name []string) bool {
+		if len(pattern) == 0 {
+			// Matching a directory matches everything in it
+			return true
+		}
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if match(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		ok, _ := path.Match(pattern[0], name[0])
+		return ok && match(pattern[1:], name[1:])
+	}
+	return match(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(name, "/"))
+}
+
+// matchAny reports whether name matches any of patterns.
+func matchAny(patterns []string, name string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		return matchGlob(pattern, name)
+	})
+}
+
+// mrpackDependencies returns the dependencies of a modpack for the game and loader version of the instance.
+func mrpackDependencies(inst Instance) map[string]string {
+	dependencies := map[string]string{"minecraft": inst.GameVersion}
+	for name, loader := range mrpackLoaders {
+		if loader != inst.Loader {
+			continue
+		}
+		version := inst.LoaderVersion
+		if loader == meta.LoaderForge {
+			version = strings.TrimPrefix(version, inst.GameVersion+"-")
+		}
+		dependencies[name] = version
+	}
+	return dependencies
+}
+
+// fileHashes returns the hashes and size of the file at path.
+func fileHashes(path string) (MrpackHashes, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return MrpackHashes{}, 0, err
+	}
+	defer f.Close()
+	h1, h512 := sha1.New(), sha512.New()
+	size, err := io.Copy(io.MultiWriter(h1, h512), f)
+	if err != nil {
+		return MrpackHashes{}, 0, err
+	}
+	return MrpackHashes{Sha1: hex.EncodeToString(h1.Sum(nil)), Sha512: hex.EncodeToString(h512.Sum(nil))}, size, nil
+}
+
+// ExportMrpack writes the instance to w as a Modrinth modpack (.mrpack), and returns its index and the paths of
+// all files packed as overrides.
+//
+// The game version and mod loader are taken from the instance. Mods installed with AddMods which haven't been
+// changed since are referenced by their download URL and hashes, and all other files are packed as overrides.
+// Files of the launcher, such as the instance configuration, are never exported.
+func ExportMrpack(w io.Writer, inst Instance, options MrpackExportOptions) (MrpackIndex, []string, error) {
+	mods, err := inst.Mods()
+	if err != nil {
+		return MrpackIndex{}, nil, err
+	}
+	index := MrpackIndex{
+		FormatVersion: 1,
+		Game:          "minecraft",
+		VersionID:     cmp.Or(options.Version, "1.0.0"),
+		Name:          cmp.Or(options.Name, inst.Name),
+		Summary:       options.Summary,
+		Files:         []MrpackFile{},
+		Dependencies:  mrpackDependencies(inst),
+	}
+
+	var overrides []string
+	err = filepath.WalkDir(inst.Dir(), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(inst.Dir(), path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if matchAny(mrpackLauncherFiles, name) || matchAny(options.Exclude, name) {
+			return nil
+		}
+		if len(options.Include) > 0 && !matchAny(options.Include, name) {
+			return nil
+		}
+
+		if file, ok := strings.CutPrefix(name, "mods/"); ok {
+			if i := slices.IndexFunc(mods, func(mod Mod) bool { return mod.File == file }); i >= 0 {
+				hashes, size, err := fileHashes(path)
+				if err != nil {
+					return err
+				}
+				// Changed files are packed as overrides
+				if hashes.Sha1 == mods[i].Sha1 {
+					index.Files = append(index.Files, MrpackFile{
+						Path:      name,
+						Hashes:    hashes,
+						Downloads: []string{mods[i].URL},
+						FileSize:  size,
+					})
+					return nil
+				}
+			}
+		}
+		overrides = append(overrides, name)
+		return nil
+	})
+	if err != nil {
+		return MrpackIndex{}, nil, fmt.Errorf("read instance files: %w", err)
+	}
+
+	zw := zip.NewWriter(w)
+	iw, err := zw.Create(MrpackIndexName)
+	if err != nil {
+		return MrpackIndex{}, nil, err
+	}
+	encoder := json.NewEncoder(iw)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(index); err != nil {
+		return MrpackIndex{}, nil, err
+	}
+	for _, name := range overrides {
+		if err := addZipFile(zw, "overrides/"+name, filepath.Join(inst.Dir(), filepath.FromSlash(name))); err != nil {
+			return MrpackIndex{}, nil, fmt.Errorf("pack %q: %w", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return MrpackIndex{}, nil, err
+	}
+	return index, overrides, nil
+}
+
+// addZipFile writes the file at path to zw as name.
+func addZipFile(zw *zip.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	fw, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, f)
+	return err
+}
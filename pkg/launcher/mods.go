@@ -0,0 +1,419 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/telecter/cmd-launcher/internal/meta"
+	"github.com/telecter/cmd-launcher/internal/network"
+)
+
+// ErrModsUnsupported is returned when mods are managed for an instance without a mod loader.
+var ErrModsUnsupported = errors.New("instance has no mod loader")
+
+// ErrNoCompatibleMod is returned when no version of a mod supports the loader and game version of an instance.
+var ErrNoCompatibleMod = errors.New("no compatible version found")
+
+// A Mod is a mod installed in an instance from Modrinth.
+type Mod struct {
+	ProjectID    string   `json:"project_id"`
+	Slug         string   `json:"slug"`
+	Title        string   `json:"title"`
+	VersionID    string   `json:"version_id"`
+	Version      string   `json:"version"`
+	File         string   `json:"file"` // Name of the file in the mods directory
+	URL          string   `json:"url"`
+	Sha1         string   `json:"sha1"`
+	Sha512       string   `json:"sha512"`
+	Size         int64    `json:"size"`
+	Dependencies []string `json:"dependencies,omitempty"` // Project IDs of required mods
+	Dependency   bool     `json:"dependency,omitempty"`   // Whether the mod was only installed as a dependency of another mod
+}
+
+// A ModUpdate is a mod which was updated to a newer version.
+type ModUpdate struct {
+	Old Mod
+	New Mod
+	Err error // Why the mod was not updated, such as ErrNoCompatibleMod. New is empty if it is set.
+}
+
+// ModResolvedEvent is called when a mod to be installed has been resolved.
+type ModResolvedEvent struct {
+	Mod Mod
+}
+
+// ModsDir returns the directory of the instance's mods.
+func (inst Instance) ModsDir() string {
+	return filepath.Join(inst.Dir(), "mods")
+}
+
+func modLockPath(inst Instance) string {
+	return filepath.Join(inst.Dir(), "mods.lock.json")
+}
+
+// Mods returns the mods installed in the instance with AddMods, as recorded in its lockfile.
+func (inst Instance) Mods() ([]Mod, error) {
+	data, err := os.ReadFile(modLockPath(inst))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read mod lockfile: %w", err)
+	}
+	var lock struct {
+		Mods []Mod `json:"mods"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parse mod lockfile: %w", err)
+	}
+	return lock.Mods, nil
+}
+
+// writeMods writes the lockfile of the instance, sorted by slug.
+func writeMods(inst Instance, mods []Mod) error {
+	mods = slices.Clone(mods)
+	slices.SortFunc(mods, func(a, b Mod) int {
+		return strings.Compare(a.Slug, b.Slug)
+	})
+	data, _ := json.MarshalIndent(struct {
+		Mods []Mod `json:"mods"`
+	}{mods}, "", "  ")
+	if err := os.WriteFile(modLockPath(inst), data, 0644); err != nil {
+		return fmt.Errorf("write mod lockfile: %w", err)
+	}
+	return nil
+}
+
+// modLoaders returns the Modrinth loaders whose mods can be used with loader.
+func modLoaders(loader meta.Loader) ([]string, error) {
+	switch loader {
+	case meta.LoaderVanilla, "":
+		return nil, ErrModsUnsupported
+	case meta.LoaderQuilt:
+		// Quilt can load most Fabric mods
+		return []string{"quilt", "fabric"}, nil
+	}
+	return []string{string(loader)}, nil
+}
+
+// findMod returns the index of the mod matching query by project ID, slug or title, or -1 if there is none.
+func findMod(mods []Mod, query string) int {
+	return slices.IndexFunc(mods, func(mod Mod) bool {
+		return mod.ProjectID == query || mod.Slug == query || strings.EqualFold(mod.Title, query)
+	})
+}
+
+// SearchMods searches Modrinth for mods compatible with the loader and game version of the instance.
+func SearchMods(ctx context.Context, inst Instance, query string, limit int) ([]meta.ModrinthSearchHit, error) {
+	loaders, err := modLoaders(inst.Loader)
+	if err != nil {
+		return nil, err
+	}
+	return meta.SearchModrinth(ctx, query, loaders, inst.GameVersion, limit)
+}
+
+// A modResolver resolves mods and their required dependencies for an instance.
+type modResolver struct {
+	ctx     context.Context
+	inst    Instance
+	loaders []string
+	mods    map[string]Mod // All installed and resolved mods by project ID
+	pending []Mod          // Resolved mods which need to be downloaded
+	watcher EventWatcher
+}
+
+func newModResolver(ctx context.Context, inst Instance, mods []Mod, watcher EventWatcher) (*modResolver, error) {
+	loaders, err := modLoaders(inst.Loader)
+	if err != nil {
+		return nil, err
+	}
+	if watcher == nil {
+		watcher = func(any) {}
+	}
+	r := &modResolver{ctx: ctx, inst: inst, loaders: loaders, mods: make(map[string]Mod), watcher: watcher}
+	for _, mod := range mods {
+		r.mods[mod.ProjectID] = mod
+	}
+	return r, nil
+}
+
+// latest returns the newest version of a project compatible with the instance.
+func (r *modResolver) latest(project meta.ModrinthProject) (meta.ModrinthVersion, error) {
+	versions, err := meta.FetchModrinthVersions(r.ctx, project.ID, r.loaders, r.inst.GameVersion)
+	if err != nil {
+		return meta.ModrinthVersion{}, err
+	}
+	if len(versions) == 0 {
+		return meta.ModrinthVersion{}, fmt.Errorf("%s for %s %s: %w", project.Title, r.inst.Loader, r.inst.GameVersion, ErrNoCompatibleMod)
+	}
+	return versions[0], nil
+}
+
+// resolve adds a version of a project and all of its required dependencies which are not installed yet.
+func (r *modResolver) resolve(project meta.ModrinthProject, version meta.ModrinthVersion, dependency bool) (Mod, error) {
+	file, ok := version.PrimaryFile()
+	if !ok {
+		return Mod{}, fmt.Errorf("version %s of %s has no files", version.VersionNumber, project.Title)
+	}
+	name := filepath.Base(file.Filename)
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		return Mod{}, fmt.Errorf("invalid file name %q", file.Filename)
+	}
+	mod := Mod{
+		ProjectID:  project.ID,
+		Slug:       project.Slug,
+		Title:      project.Title,
+		VersionID:  version.ID,
+		Version:    version.VersionNumber,
+		File:       name,
+		URL:        file.URL,
+		Sha1:       file.Hashes.Sha1,
+		Sha512:     file.Hashes.Sha512,
+		Size:       file.Size,
+		Dependency: dependency,
+	}
+	// Added before its dependencies, so dependency cycles end here
+	r.mods[project.ID] = mod
+
+	for _, dep := range version.Dependencies {
+		switch dep.DependencyType {
+		case "required":
+			id, err := r.require(dep)
+			if err != nil {
+				return Mod{}, fmt.Errorf("resolve dependency of %s: %w", project.Title, err)
+			}
+			if id != "" {
+				mod.Dependencies = append(mod.Dependencies, id)
+			}
+		case "incompatible":
+			if other, ok := r.mods[dep.ProjectID]; ok && dep.ProjectID != "" {
+				return Mod{}, fmt.Errorf("%s is incompatible with %s", project.Title, other.Title)
+			}
+		}
+	}
+	r.mods[project.ID] = mod
+	r.pending = append(r.pending, mod)
+	r.watcher(ModResolvedEvent{Mod: mod})
+	return mod, nil
+}
+
+// require resolves a required dependency if it is not installed yet, and returns its project ID.
+func (r *modResolver) require(dep meta.ModrinthDependency) (string, error) {
+	projectID := dep.ProjectID
+	var version *meta.ModrinthVersion
+	if dep.VersionID != "" {
+		v, err := meta.FetchModrinthVersion(r.ctx, dep.VersionID)
+		if err != nil {
+			return "", err
+		}
+		projectID, version = v.ProjectID, &v
+	}
+	if projectID == "" {
+		// Dependencies on external files cannot be resolved
+		return "", nil
+	}
+	if _, ok := r.mods[projectID]; ok {
+		return projectID, nil
+	}
+	project, err := meta.FetchModrinthProject(r.ctx, projectID)
+	if err != nil {
+		return "", err
+	}
+	if version == nil {
+		v, err := r.latest(project)
+		if err != nil {
+			return "", err
+		}
+		version = &v
+	}
+	_, err = r.resolve(project, *version, true)
+	return projectID, err
+}
+
+// download downloads all pending mods into the mods directory, verifying their checksums.
+func (r *modResolver) download() error {
+	var entries []network.DownloadEntry
+	for _, mod := range r.pending {
+		entries = append(entries, network.DownloadEntry{
+			URL:  mod.URL,
+			Path: filepath.Join(r.inst.ModsDir(), mod.File),
+			Sha1: mod.Sha1,
+			Size: mod.Size,
+		})
+	}
+	if err := download(r.ctx, entries, nil, r.watcher); err != nil {
+		return fmt.Errorf("download mods: %w", err)
+	}
+	return nil
+}
+
+// result returns all mods of the resolver.
+func (r *modResolver) result() []Mod {
+	var mods []Mod
+	for _, mod := range r.mods {
+		mods = append(mods, mod)
+	}
+	return mods
+}
+
+// AddMods installs the newest versions of the specified Modrinth projects which are compatible with the
+// loader and game version of the instance, along with their required dependencies. Projects are specified
+// by their ID or slug.
+//
+// Mods are verified with their SHA-1 checksum and recorded in the instance's lockfile. The added mods,
+// including dependencies, are returned. Projects which are already installed are skipped.
+func AddMods(ctx context.Context, inst Instance, projects []string, watcher EventWatcher) ([]Mod, error) {
+	mods, err := inst.Mods()
+	if err != nil {
+		return nil, err
+	}
+	r, err := newModResolver(ctx, inst, mods, watcher)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range projects {
+		project, err := meta.FetchModrinthProject(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if mod, ok := r.mods[project.ID]; ok {
+			// Installed mods are kept, but are no longer considered a dependency
+			mod.Dependency = false
+			r.mods[project.ID] = mod
+			continue
+		}
+		if project.ProjectType != "" && project.ProjectType != "mod" {
+			return nil, fmt.Errorf("%s is a %s, not a mod", project.Title, project.ProjectType)
+		}
+		version, err := r.latest(project)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.resolve(project, version, false); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.download(); err != nil {
+		return nil, err
+	}
+	if err := writeMods(inst, r.result()); err != nil {
+		return nil, err
+	}
+	return r.pending, nil
+}
+
+// RemoveMods removes the specified mods from the instance, along with dependencies no other mod requires anymore.
+// Mods are specified by their project ID, slug or title.
+//
+// The removed mods are returned.
+func RemoveMods(inst Instance, projects []string) ([]Mod, error) {
+	mods, err := inst.Mods()
+	if err != nil {
+		return nil, err
+	}
+	var removed []Mod
+	for _, query := range projects {
+		i := findMod(mods, query)
+		if i < 0 {
+			return nil, fmt.Errorf("mod %q is not installed", query)
+		}
+		removed = append(removed, mods[i])
+		mods = slices.Delete(mods, i, i+1)
+	}
+
+	// Remove dependencies until every remaining one is required by another mod
+	for {
+		i := slices.IndexFunc(mods, func(mod Mod) bool {
+			return mod.Dependency && !slices.ContainsFunc(mods, func(other Mod) bool {
+				return slices.Contains(other.Dependencies, mod.ProjectID)
+			})
+		})
+		if i < 0 {
+			break
+		}
+		removed = append(removed, mods[i])
+		mods = slices.Delete(mods, i, i+1)
+	}
+
+	for _, mod := range removed {
+		if err := os.Remove(filepath.Join(inst.ModsDir(), mod.File)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("remove mod file: %w", err)
+		}
+	}
+	if err := writeMods(inst, mods); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// UpdateMods updates the specified mods of the instance to their newest compatible version, installing any new
+// required dependencies. If no mods are specified, all mods are updated.
+//
+// The updated mods are returned, including mods which have no compatible version anymore, with Err set.
+func UpdateMods(ctx context.Context, inst Instance, projects []string, watcher EventWatcher) ([]ModUpdate, error) {
+	mods, err := inst.Mods()
+	if err != nil {
+		return nil, err
+	}
+	selected := mods
+	if len(projects) > 0 {
+		selected = nil
+		for _, query := range projects {
+			i := findMod(mods, query)
+			if i < 0 {
+				return nil, fmt.Errorf("mod %q is not installed", query)
+			}
+			selected = append(selected, mods[i])
+		}
+	}
+
+	r, err := newModResolver(ctx, inst, mods, watcher)
+	if err != nil {
+		return nil, err
+	}
+	var updates []ModUpdate
+	for _, old := range selected {
+		project, err := meta.FetchModrinthProject(ctx, old.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		version, err := r.latest(project)
+		if errors.Is(err, ErrNoCompatibleMod) {
+			// The installed version is kept, so the other mods can still be updated
+			updates = append(updates, ModUpdate{Old: old, Err: err})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if version.ID == old.VersionID {
+			continue
+		}
+		mod, err := r.resolve(project, version, old.Dependency)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, ModUpdate{Old: old, New: mod})
+	}
+	if err := r.download(); err != nil {
+		return nil, err
+	}
+	for _, update := range updates {
+		if update.Err != nil || update.Old.File == update.New.File {
+			continue
+		}
+		if err := os.Remove(filepath.Join(inst.ModsDir(), update.Old.File)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("remove old mod file: %w", err)
+		}
+	}
+	if err := writeMods(inst, r.result()); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
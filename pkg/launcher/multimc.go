@@ -0,0 +1,349 @@
+package launcher
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/telecter/cmd-launcher/internal/meta"
+)
+
+// A MultiMCComponent is a component of a MultiMC or Prism Launcher instance, such as the game or a mod loader.
+type MultiMCComponent struct {
+	UID            string `json:"uid"`
+	Name           string `json:"cachedName"`
+	Version        string `json:"version"`
+	DependencyOnly bool   `json:"dependencyOnly"`
+}
+
+// An UnsupportedComponentError describes a part of a MultiMC or Prism Launcher instance which was not imported.
+type UnsupportedComponentError struct {
+	Component string // UID of the component, or the name of the file or setting
+	Version   string
+	Reason    string
+}
+
+func (e *UnsupportedComponentError) Error() string {
+	if e.Version != "" {
+		return fmt.Sprintf("%s %s: %s", e.Component, e.Version, e.Reason)
+	}
+	return fmt.Sprintf("%s: %s", e.Component, e.Reason)
+}
+
+// MultiMCImportOptions configures how a MultiMC or Prism Launcher instance is imported.
+type MultiMCImportOptions struct {
+	InstanceOptions      // Only the name and configuration are used
+	Move            bool // Move the game directory instead of copying it
+}
+
+// multiMCLoaders maps the UIDs of mod loader components to loaders.
+var multiMCLoaders = map[string]meta.Loader{
+	"net.fabricmc.fabric-loader": meta.LoaderFabric,
+	"org.quiltmc.quilt-loader":   meta.LoaderQuilt,
+	"net.minecraftforge":         meta.LoaderForge,
+	"net.neoforged":              meta.LoaderNeoForge,
+}
+
+// multiMCImplicit contains the UIDs of components which are included with the game or a mod loader.
+var multiMCImplicit = []string{"org.lwjgl", "org.lwjgl3", "net.fabricmc.intermediary", "org.quiltmc.hashed"}
+
+// readMultiMCConfig reads the key-value pairs of an instance.cfg file. Sections are ignored.
+func readMultiMCConfig(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	config := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "[") || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil && strings.HasPrefix(value, `"`) {
+			value = unquoted
+		}
+		config[strings.TrimSpace(key)] = value
+	}
+	return config, scanner.Err()
+}
+
+// multiMCVersions returns the game version, loader and loader version of the components of an instance,
+// and the components which can't be represented.
+func multiMCVersions(components []MultiMCComponent) (gameVersion string, loader meta.Loader, loaderVersion string, unsupported []error) {
+	loader = meta.LoaderVanilla
+	for _, component := range components {
+		switch l, ok := multiMCLoaders[component.UID]; {
+		case component.UID == "net.minecraft":
+			gameVersion = component.Version
+		case ok && loader == meta.LoaderVanilla:
+			loader, loaderVersion = l, component.Version
+		case ok:
+			unsupported = append(unsupported, &UnsupportedComponentError{component.UID, component.Version, "only one mod loader is supported"})
+		case slices.Contains(multiMCImplicit, component.UID):
+		default:
+			unsupported = append(unsupported, &UnsupportedComponentError{component.UID, component.Version, "component is not supported"})
+		}
+	}
+	if loader == meta.LoaderForge && !strings.HasPrefix(loaderVersion, gameVersion+"-") {
+		loaderVersion = gameVersion + "-" + loaderVersion
+	}
+	return gameVersion, loader, loaderVersion, unsupported
+}
+
+// splitCommand splits a command line into arguments with shell-style quoting.
+//
+// Arguments are separated by whitespace, which can be included in single quotes, in double quotes or after a
+// backslash. A backslash only escapes whitespace, quotes and backslashes, so Windows paths are kept as they are.
+// Within double quotes, it only escapes double quotes and backslashes.
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range command {
+		switch {
+		case escaped:
+			special := `"\`
+			if quote == 0 {
+				special = "\"'\\ \t"
+			}
+			if !strings.ContainsRune(special, r) {
+				arg.WriteRune('\\')
+			}
+			arg.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in command")
+	}
+	if escaped {
+		arg.WriteRune('\\')
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// applyMultiMCConfig sets the values of config which are overridden in the MultiMC instance configuration cfg,
+// and returns the settings which can't be represented.
+func applyMultiMCConfig(config *InstanceConfig, cfg map[string]string) (unsupported []error) {
+	atoi := func(key string) (int, bool) {
+		n, err := strconv.Atoi(cfg[key])
+		return n, err == nil && n > 0
+	}
+	if cfg["OverrideMemory"] == "true" {
+		if n, ok := atoi("MinMemAlloc"); ok {
+			config.MinMemory = n
+		}
+		if n, ok := atoi("MaxMemAlloc"); ok {
+			config.MaxMemory = n
+		}
+	}
+	// Older versions of MultiMC override the Java location and arguments together
+	if cfg["OverrideJavaLocation"] == "true" || cfg["OverrideJava"] == "true" {
+		if cfg["JavaPath"] != "" {
+			config.Java = cfg["JavaPath"]
+		}
+	}
+	if cfg["OverrideJavaArgs"] == "true" || cfg["OverrideJava"] == "true" {
+		config.JavaArgs = cfg["JvmArgs"]
+	}
+	if cfg["OverrideWindow"] == "true" {
+		if n, ok := atoi("MinecraftWinWidth"); ok {
+			config.WindowResolution.Width = n
+		}
+		if n, ok := atoi("MinecraftWinHeight"); ok {
+			config.WindowResolution.Height = n
+		}
+	}
+	// Custom commands receive the same environment variables as hooks
+	if cfg["OverrideCommands"] == "true" {
+		config.Hooks.PreLaunch = cfg["PreLaunchCommand"]
+		config.Hooks.PostExit = cfg["PostExitCommand"]
+		wrapper, err := splitCommand(cfg["WrapperCommand"])
+		if err != nil {
+			unsupported = append(unsupported, &UnsupportedComponentError{Component: "WrapperCommand", Reason: err.Error()})
+		}
+		config.Wrapper = wrapper
+	}
+	return unsupported
+}
+
+// ImportMultiMC creates an instance from the MultiMC or Prism Launcher instance in dir.
+//
+// The game version and mod loader are taken from the components in mmc-pack.json, and memory, Java, window and
+// custom command settings overridden in instance.cfg replace those of options. If the name is empty, the name of the
+// MultiMC instance is used. The game directory is copied, or moved if Move is set, into the new instance.
+//
+// Components, patches and settings which can't be represented are returned as UnsupportedComponentErrors.
+// If anything fails, the instance is removed again.
+func ImportMultiMC(ctx context.Context, dir string, options MultiMCImportOptions) (inst Instance, unsupported []error, err error) {
+	cfg, err := readMultiMCConfig(filepath.Join(dir, "instance.cfg"))
+	if err != nil {
+		return Instance{}, nil, fmt.Errorf("read instance.cfg: %w", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "mmc-pack.json"))
+	if err != nil {
+		return Instance{}, nil, fmt.Errorf("read mmc-pack.json: %w", err)
+	}
+	var pack struct {
+		Components []MultiMCComponent `json:"components"`
+	}
+	if err := json.Unmarshal(data, &pack); err != nil {
+		return Instance{}, nil, fmt.Errorf("parse mmc-pack.json: %w", err)
+	}
+
+	instOptions := options.InstanceOptions
+	instOptions.GameVersion, instOptions.Loader, instOptions.LoaderVersion, unsupported = multiMCVersions(pack.Components)
+	if instOptions.GameVersion == "" {
+		return Instance{}, nil, fmt.Errorf("instance has no net.minecraft component")
+	}
+	for _, name := range []string{"patches", "jarmods"} {
+		entries, _ := os.ReadDir(filepath.Join(dir, name))
+		for _, entry := range entries {
+			unsupported = append(unsupported, &UnsupportedComponentError{Component: name + "/" + entry.Name(), Reason: "custom patches are not supported"})
+		}
+	}
+	if instOptions.Name == "" {
+		instOptions.Name = cfg["name"]
+	}
+	if filepath.Base(instOptions.Name) != instOptions.Name {
+		return Instance{}, nil, fmt.Errorf("invalid instance name")
+	}
+	unsupported = append(unsupported, applyMultiMCConfig(&instOptions.Config, cfg)...)
+
+	gameDir := filepath.Join(dir, ".minecraft")
+	if _, err := os.Stat(gameDir); errors.Is(err, os.ErrNotExist) {
+		gameDir = filepath.Join(dir, "minecraft")
+	}
+
+	inst, err = CreateInstanceContext(ctx, instOptions)
+	if err != nil {
+		return Instance{}, nil, fmt.Errorf("create instance: %w", err)
+	}
+	// Captured, as inst is zero when an error is returned
+	instDir := inst.Dir()
+	defer func() {
+		if err != nil {
+			os.RemoveAll(instDir)
+		}
+	}()
+
+	// Moved entries are put back before the instance is removed, so a failed import never loses any files
+	var moved, copied []string
+	defer func() {
+		if err != nil {
+			for _, name := range moved {
+				os.Rename(filepath.Join(instDir, name), filepath.Join(gameDir, name))
+			}
+		}
+	}()
+
+	entries, err := os.ReadDir(gameDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Instance{}, nil, fmt.Errorf("read game directory: %w", err)
+	}
+	for _, entry := range entries {
+		src, dest := filepath.Join(gameDir, entry.Name()), filepath.Join(instDir, entry.Name())
+		if entry.Name() == "instance.toml" || entry.Name() == "instance.json" {
+			continue
+		}
+		if options.Move {
+			if err := os.Rename(src, dest); err == nil {
+				moved = append(moved, entry.Name())
+				continue
+			}
+		}
+		if err := copyTree(src, dest); err != nil {
+			return Instance{}, nil, fmt.Errorf("copy game directory: %w", err)
+		}
+		copied = append(copied, entry.Name())
+	}
+	// Copied sources are only removed once the whole game directory has been imported
+	if options.Move {
+		for _, name := range copied {
+			os.RemoveAll(filepath.Join(gameDir, name))
+		}
+	}
+	return inst, unsupported, nil
+}
+
+// copyTree copies the file or directory at src to dest, keeping symlinks and file permissions.
+func copyTree(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case d.Type()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
@@ -0,0 +1,130 @@
+package launcher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/telecter/cmd-launcher/internal/network"
+)
+
+const (
+	progressInterval = 100 * time.Millisecond // Minimum time between two DownloadingEvents for partial progress
+	rateInterval     = 500 * time.Millisecond // Minimum time between two throughput samples
+	rateSmoothing    = 0.3                    // Weight of the newest throughput sample
+)
+
+// A downloadProgress tracks the byte-level progress of a set of downloads and reports it to an EventWatcher.
+type downloadProgress struct {
+	mu      sync.Mutex
+	watcher EventWatcher
+	stopped bool
+
+	files     int
+	completed int
+	total     int64
+	done      int64
+	sizes     map[string]int64
+	written   map[string]int64
+
+	lastReport time.Time
+	lastSample time.Time
+	lastDone   int64
+	rate       float64
+}
+
+// newDownloadProgress creates a downloadProgress for entries, using their known sizes as the total.
+func newDownloadProgress(entries []network.DownloadEntry, watcher EventWatcher) *downloadProgress {
+	p := &downloadProgress{
+		watcher:    watcher,
+		files:      len(entries),
+		sizes:      make(map[string]int64),
+		written:    make(map[string]int64),
+		lastSample: time.Now(),
+	}
+	for _, entry := range entries {
+		p.sizes[entry.Path] = entry.Size
+		p.total += entry.Size
+	}
+	return p
+}
+
+// watch handles a progress event from the network package.
+func (p *downloadProgress) watch(event any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return
+	}
+
+	switch e := event.(type) {
+	case network.DownloadStartedEvent:
+		// Sizes not present in the metadata are only known once the server responds
+		if p.sizes[e.Entry.Path] == 0 && e.Size > 0 {
+			p.sizes[e.Entry.Path] = e.Size
+			p.total += e.Size
+		}
+		p.watcher(DownloadStartedEvent{
+			URL:  e.Entry.URL,
+			Path: e.Entry.Path,
+			Size: p.sizes[e.Entry.Path],
+		})
+	case network.DownloadProgressEvent:
+		p.done += e.Written - p.written[e.Entry.Path]
+		p.written[e.Entry.Path] = e.Written
+		if time.Since(p.lastReport) >= progressInterval {
+			p.report()
+		}
+	case network.DownloadFinishedEvent:
+		if e.Err != nil {
+			return
+		}
+		p.completed++
+		// The size is only known now if neither the metadata nor the server specified it
+		if p.sizes[e.Entry.Path] == 0 {
+			p.sizes[e.Entry.Path] = p.written[e.Entry.Path]
+			p.total += p.written[e.Entry.Path]
+		}
+		p.watcher(DownloadFinishedEvent{
+			URL:  e.Entry.URL,
+			Path: e.Entry.Path,
+			Size: p.written[e.Entry.Path],
+		})
+		p.report()
+	}
+}
+
+// report sends a DownloadingEvent with the current progress, throughput and estimated time remaining.
+func (p *downloadProgress) report() {
+	now := time.Now()
+	if elapsed := now.Sub(p.lastSample); elapsed >= rateInterval {
+		sample := float64(p.done-p.lastDone) / elapsed.Seconds()
+		if p.rate == 0 {
+			p.rate = sample
+		} else {
+			p.rate = rateSmoothing*sample + (1-rateSmoothing)*p.rate
+		}
+		p.lastSample = now
+		p.lastDone = p.done
+	}
+
+	var eta time.Duration
+	if p.rate > 0 && p.total > p.done {
+		eta = time.Duration(float64(p.total-p.done) / p.rate * float64(time.Second))
+	}
+	p.lastReport = now
+	p.watcher(DownloadingEvent{
+		Completed:      p.completed,
+		Total:          p.files,
+		BytesCompleted: p.done,
+		BytesTotal:     p.total,
+		Throughput:     p.rate,
+		ETA:            eta,
+	})
+}
+
+// stop prevents any further events from being sent to the watcher.
+func (p *downloadProgress) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopped = true
+}
@@ -0,0 +1,188 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/telecter/cmd-launcher/internal/meta"
+	env "github.com/telecter/cmd-launcher/pkg"
+)
+
+// ErrJavaRuntimeNotInstalled is returned when a Java runtime which is not installed is verified or removed.
+var ErrJavaRuntimeNotInstalled = errors.New("runtime is not installed")
+
+// A JavaRuntime is a Mojang-provided Java runtime component, such as "java-runtime-delta".
+type JavaRuntime struct {
+	Component string
+	Version   string // Version name, for example "21.0.7". Empty if the component is not available.
+	Released  time.Time
+	Available bool  // Whether Mojang provides the component for the current system
+	Installed bool  // Whether the component is present in the Java directory
+	Size      int64 // Disk usage in bytes, if installed
+}
+
+// Dir returns the directory the runtime is installed in.
+func (runtime JavaRuntime) Dir() string {
+	return filepath.Join(env.JavaDir, runtime.Component)
+}
+
+// Java returns the path to the Java executable of the runtime.
+func (runtime JavaRuntime) Java() string {
+	return javaExecutable(runtime.Dir())
+}
+
+// checkComponent returns an error if component is not a valid name of a Java runtime component.
+func checkComponent(component string) error {
+	if component == "" || component == "." || component == ".." || filepath.Base(component) != component {
+		return fmt.Errorf("invalid Java runtime component %q", component)
+	}
+	return nil
+}
+
+// FetchJavaRuntimes returns all Mojang-provided Java runtimes which are available for the current system
+// or installed, sorted by component name.
+func FetchJavaRuntimes(ctx context.Context) ([]JavaRuntime, error) {
+	list, err := meta.FetchJavaManifestList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch Java manifest list: %w", err)
+	}
+	runtimes := make(map[string]JavaRuntime)
+	for component, versions := range list[meta.JavaPlatform()] {
+		runtime := JavaRuntime{Component: component}
+		if len(versions) > 0 {
+			runtime.Available = true
+			runtime.Version = versions[0].Version.Name
+			runtime.Released = versions[0].Version.Released
+		}
+		runtimes[component] = runtime
+	}
+
+	entries, err := os.ReadDir(env.JavaDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("read Java directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		runtime, ok := runtimes[entry.Name()]
+		if !ok {
+			runtime = JavaRuntime{Component: entry.Name()}
+		}
+		runtime.Installed = true
+		runtime.Size, err = dirSize(runtime.Dir())
+		if err != nil {
+			return nil, err
+		}
+		runtimes[entry.Name()] = runtime
+	}
+
+	var sorted []JavaRuntime
+	for _, runtime := range runtimes {
+		if runtime.Available || runtime.Installed {
+			sorted = append(sorted, runtime)
+		}
+	}
+	slices.SortFunc(sorted, func(a, b JavaRuntime) int {
+		return strings.Compare(a.Component, b.Component)
+	})
+	return sorted, nil
+}
+
+// InstallJavaRuntime downloads the specified Mojang-provided Java runtime component, reporting download events to watcher.
+//
+// Only files which are missing or do not match their checksum are downloaded, so this also repairs an installed runtime.
+func InstallJavaRuntime(ctx context.Context, component string, watcher EventWatcher) error {
+	if err := checkComponent(component); err != nil {
+		return err
+	}
+	manifest, err := meta.FetchJavaManifest(ctx, component)
+	if err != nil {
+		return fmt.Errorf("fetch Java manifest: %w", err)
+	}
+	entries, symlinks := manifest.DownloadEntries(component)
+	if err := download(ctx, entries, symlinks, watcher); err != nil {
+		return fmt.Errorf("download Java runtime: %w", err)
+	}
+	return nil
+}
+
+// VerifyJavaRuntime checks every file of an installed Java runtime against its manifest, and returns
+// the files which are missing or do not match their SHA-1 checksum.
+//
+// The runtime can be repaired with InstallJavaRuntime. If it is not installed, ErrJavaRuntimeNotInstalled is returned.
+func VerifyJavaRuntime(ctx context.Context, component string) ([]StorageFile, error) {
+	if err := checkComponent(component); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(JavaRuntime{Component: component}.Dir()); errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%q: %w", component, ErrJavaRuntimeNotInstalled)
+	} else if err != nil {
+		return nil, err
+	}
+	manifest, err := meta.FetchJavaManifest(ctx, component)
+	if err != nil {
+		return nil, fmt.Errorf("fetch Java manifest: %w", err)
+	}
+	entries, symlinks := manifest.DownloadEntries(component)
+
+	var invalid []StorageFile
+	for _, entry := range entries {
+		invalid = append(invalid, StorageFile{Category: StorageJava, Path: entry.Path, Size: entry.Size})
+	}
+	for link := range symlinks {
+		invalid = append(invalid, StorageFile{Category: StorageJava, Path: link})
+	}
+	slices.SortFunc(invalid, func(a, b StorageFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return invalid, nil
+}
+
+// RemoveJavaRuntime removes an installed Java runtime and its cached manifest.
+//
+// If an instance still uses the runtime, it is downloaded again the next time the instance is started.
+func RemoveJavaRuntime(component string) error {
+	if err := checkComponent(component); err != nil {
+		return err
+	}
+	dir := JavaRuntime{Component: component}.Dir()
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%q: %w", component, ErrJavaRuntimeNotInstalled)
+	} else if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("remove Java runtime: %w", err)
+	}
+	if err := os.Remove(meta.JavaManifestPath(component)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove Java manifest: %w", err)
+	}
+	return nil
+}
+
+// dirSize returns the total size of all files in dir.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,358 @@
+package launcher
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/telecter/cmd-launcher/internal/meta"
+	env "github.com/telecter/cmd-launcher/pkg"
+)
+
+// A StorageCategory is a kind of data shared between all instances.
+type StorageCategory string
+
+const (
+	StorageLibraries StorageCategory = "libraries"
+	StorageAssets    StorageCategory = "assets"
+	StorageJava      StorageCategory = "java"
+	StorageCaches    StorageCategory = "caches"
+)
+
+// StorageCategories contains all storage categories.
+var StorageCategories = []StorageCategory{StorageLibraries, StorageAssets, StorageJava, StorageCaches}
+
+// Dir returns the directory in which data of the category is stored.
+func (category StorageCategory) Dir() string {
+	switch category {
+	case StorageLibraries:
+		return env.LibrariesDir
+	case StorageAssets:
+		return env.AssetsDir
+	case StorageJava:
+		return env.JavaDir
+	case StorageCaches:
+		return env.CachesDir
+	}
+	return ""
+}
+
+// StorageUsage is the disk usage of a storage category.
+type StorageUsage struct {
+	Category StorageCategory
+	Files    int
+	Size     int64 // Total size in bytes
+}
+
+// A StorageFile is a single file in shared storage.
+type StorageFile struct {
+	Category StorageCategory
+	Path     string
+	Size     int64
+}
+
+// FetchStorageUsage returns the disk usage of each storage category.
+func FetchStorageUsage() ([]StorageUsage, error) {
+	var usages []StorageUsage
+	for _, category := range StorageCategories {
+		usage := StorageUsage{Category: category}
+		err := walkStorage(category, func(file StorageFile) error {
+			usage.Files++
+			usage.Size += file.Size
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
+
+// VerifyStorage checks every stored file with a known SHA-1 checksum and returns those which do not match it.
+//
+// Checksums are known for all assets, and for any other file used by an instance. Removing an invalid
+// file causes it to be downloaded again the next time it is needed.
+func VerifyStorage(ctx context.Context) ([]StorageFile, error) {
+	refs, err := resolveStorageRefs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	objects := filepath.Join(env.AssetsDir, "objects")
+
+	var invalid []StorageFile
+	for _, category := range StorageCategories {
+		err := walkStorage(category, func(file StorageFile) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			sum := refs.files[file.Path]
+			if sum == "" && isWithin(objects, file.Path) {
+				sum = filepath.Base(file.Path)
+			}
+			if sum == "" {
+				return nil
+			}
+			actual, err := fileSha1(file.Path)
+			if err != nil {
+				return err
+			}
+			if actual != sum {
+				invalid = append(invalid, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return invalid, nil
+}
+
+// FetchUnusedStorage returns all stored files which are not used by any instance.
+//
+// The files used by each instance are computed from its version metadata, so this may make network requests
+// if metadata is not cached. If the metadata of any instance cannot be resolved, an error is returned, as no
+// file could safely be considered unused. Version lists, such as the version manifest, are never unused.
+func FetchUnusedStorage(ctx context.Context) ([]StorageFile, error) {
+	refs, err := resolveStorageRefs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var unused []StorageFile
+	for _, category := range StorageCategories {
+		err := walkStorage(category, func(file StorageFile) error {
+			if !refs.uses(category, file.Path) {
+				unused = append(unused, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return unused, nil
+}
+
+// RemoveStorage removes the specified files, along with any directories left empty.
+func RemoveStorage(files []StorageFile) error {
+	dirs := make(map[string]StorageCategory)
+	for _, file := range files {
+		if err := os.Remove(file.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove file %q: %w", file.Path, err)
+		}
+		dirs[filepath.Dir(file.Path)] = file.Category
+	}
+	for dir, category := range dirs {
+		root := category.Dir()
+		for isWithin(root, dir) && dir != root {
+			if os.Remove(dir) != nil {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+	return nil
+}
+
+// walkStorage calls f for every file or symlink stored in the specified category.
+func walkStorage(category StorageCategory, f func(file StorageFile) error) error {
+	err := filepath.WalkDir(category.Dir(), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return f(StorageFile{Category: category, Path: path, Size: info.Size()})
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s directory: %w", category, err)
+	}
+	return nil
+}
+
+// storageRefs contains the stored files used by instances.
+type storageRefs struct {
+	files map[string]string // Paths of used files, with their SHA-1 checksums, if known
+	dirs  []string          // Directories in which all files are used
+}
+
+// uses reports whether the file at path is used by an instance.
+func (refs storageRefs) uses(category StorageCategory, path string) bool {
+	if _, ok := refs.files[path]; ok {
+		return true
+	}
+	for _, dir := range refs.dirs {
+		if isWithin(dir, path) {
+			return true
+		}
+	}
+	// Validators of cached data
+	if base, ok := strings.CutSuffix(path, ".info"); ok {
+		return refs.uses(category, base)
+	}
+	switch category {
+	case StorageLibraries:
+		// Checksums saved next to Maven libraries
+		if base, ok := strings.CutSuffix(path, ".sha1"); ok {
+			_, used := refs.files[base]
+			return used
+		}
+	case StorageAssets:
+		// Only objects, indexes and reconstructed legacy assets are managed by the launcher
+		for _, name := range []string{"objects", "indexes", "virtual"} {
+			if isWithin(filepath.Join(env.AssetsDir, name), path) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// resolveStorageRefs computes the stored files used by all instances from their version metadata.
+func resolveStorageRefs(ctx context.Context) (storageRefs, error) {
+	refs := storageRefs{files: make(map[string]string)}
+	for _, path := range meta.ListCachePaths() {
+		refs.files[path] = ""
+	}
+
+	insts, err := FetchAllInstances()
+	if err != nil {
+		return storageRefs{}, err
+	}
+	for _, inst := range insts {
+		if err := refs.add(ctx, inst); err != nil {
+			return storageRefs{}, fmt.Errorf("resolve files of instance %q: %w", inst.Name, err)
+		}
+	}
+	return refs, nil
+}
+
+// add adds all stored files used by inst.
+func (refs *storageRefs) add(ctx context.Context, inst Instance) error {
+	version, err := meta.FetchAllVersionMeta(ctx, inst.Loader, inst.GameVersion, inst.LoaderVersion)
+	if err != nil {
+		return fmt.Errorf("retrieve metadata: %w", err)
+	}
+	refs.files[meta.VersionMetaPath(version.ID)] = ""
+
+	// Libraries
+	libraries := append(version.Libraries, version.Client())
+	for _, library := range libraries {
+		if !library.ShouldInstall {
+			continue
+		}
+		if library.Artifact.URL != "" {
+			library = patchLibrary(ctx, library)
+		}
+		refs.files[library.Artifact.RuntimePath()] = library.Artifact.Sha1
+		for _, native := range library.Natives {
+			refs.files[native.Artifact.RuntimePath()] = native.Artifact.Sha1
+		}
+	}
+
+	// Loader metadata and files generated by Forge post processors
+	switch inst.Loader {
+	case meta.LoaderFabric:
+		refs.files[meta.Fabric.MetaPath(version.ID, version.LoaderID)] = ""
+	case meta.LoaderQuilt:
+		refs.files[meta.Quilt.MetaPath(version.ID, version.LoaderID)] = ""
+	case meta.LoaderForge, meta.LoaderNeoForge:
+		forge := meta.Forge
+		if inst.Loader == meta.LoaderNeoForge {
+			forge = meta.Neoforge
+		}
+		refs.files[forge.InstallerPath(version.LoaderID)] = ""
+		_, profile, err := forge.FetchMeta(ctx, version.LoaderID)
+		if err != nil {
+			return fmt.Errorf("retrieve install profile: %w", err)
+		}
+		for _, data := range profile.Data {
+			if !strings.HasPrefix(data.Client, "[") || !strings.HasSuffix(data.Client, "]") {
+				continue
+			}
+			specifier, err := meta.NewLibrarySpecifier(strings.Trim(data.Client, "[]"))
+			if err != nil {
+				continue
+			}
+			refs.files[filepath.Join(env.LibrariesDir, specifier.Path())] = ""
+		}
+	}
+
+	// Assets
+	refs.files[meta.AssetIndexPath(version.AssetIndex.ID)] = version.AssetIndex.Sha1
+	assetIndex, err := meta.DownloadAssetIndex(ctx, version)
+	if err != nil {
+		return fmt.Errorf("retrieve asset index: %w", err)
+	}
+	for _, object := range assetIndex.Objects {
+		refs.files[meta.AssetObjectPath(object.Hash)] = object.Hash
+	}
+	// Legacy assets reconstructed by their names
+	refs.dirs = append(refs.dirs, filepath.Join(env.AssetsDir, "virtual", version.AssetIndex.ID))
+
+	// Log4j configuration
+	if file := version.Logging.Client.File; inst.Config.LogConfig == "" && file.URL != "" {
+		refs.files[meta.LoggingConfigPath(file.ID)] = file.Sha1
+	}
+
+	// Java runtime
+	if inst.Config.Java == "" {
+		component := version.JavaVersion.Component
+		manifest, err := meta.FetchJavaManifest(ctx, component)
+		if errors.Is(err, meta.ErrJavaBadSystem) || errors.Is(err, meta.ErrJavaNoVersion) {
+			// A Java installation of the system is used instead
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("fetch Java manifest: %w", err)
+		}
+		refs.files[meta.JavaManifestPath(component)] = ""
+
+		dir := filepath.Join(env.JavaDir, component)
+		refs.dirs = append(refs.dirs, dir)
+		for name, file := range manifest.Files {
+			if file.Type == "file" {
+				refs.files[filepath.Join(dir, name)] = file.Downloads.Raw.Sha1
+			}
+		}
+	}
+	return nil
+}
+
+// isWithin reports whether path is inside of dir.
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// fileSha1 returns the SHA-1 checksum of the file at path.
+func fileSha1(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}